@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// fetch looks up a single asset in the Asset Delivery API and prints its description, for debugging one asset
+// without running a sync job. With -o, it also downloads the asset's contents.
+func main() {
+	version := flag.Int("version", 0, "version of the asset to fetch; defaults to the latest")
+	out := flag.String("o", "", "if set, download the asset's contents to this file, or to stdout if -")
+	formatsStr := flag.String("formats", "", "comma-separated asset formats to download in, most preferred first; defaults to the first location")
+	baseURL := flag.String("base-url", assetdelivery.DefaultBaseURL, "Asset Delivery API endpoint")
+	flag.Parse()
+
+	id, err := strconv.ParseInt(flag.Arg(0), 10, 64)
+	if err != nil {
+		logrus.WithError(err).Fatal("parse asset ID")
+	}
+
+	opts := []assetdelivery.Option{assetdelivery.WithBaseURL(*baseURL)}
+	// authenticate like the indexer, to debug assets that need an account
+	if env := os.Getenv("INDEXER_CREDENTIALS"); env != "" {
+		creds, err := assetdelivery.ParseCredentials(env)
+		if err != nil {
+			logrus.WithError(err).Fatal("parse INDEXER_CREDENTIALS")
+		}
+		opts = append(opts, assetdelivery.WithCredentials(assetdelivery.NewRotation(creds...)))
+	}
+
+	ctx := context.Background()
+	cl := assetdelivery.NewClient(resty.New(), opts...)
+
+	var stats assetdelivery.CallStats
+	description, err := cl.AssetFetchByID(ctx, id, &assetdelivery.BatchOptions{
+		SkipSigningScripts: true,
+		Version:            *version,
+		Stats:              &stats,
+	})
+	if err == nil || len(description.Errors) > 0 {
+		buf, err := json.MarshalIndent(description, "", "  ")
+		if err != nil {
+			logrus.WithError(err).Fatal("marshal description")
+		}
+		// keep stdout for the contents if they're downloaded there
+		w := os.Stdout
+		if *out == "-" {
+			w = os.Stderr
+		}
+		fmt.Fprintln(w, string(buf))
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("retries", stats.Retries).Fatal("fetch asset")
+	}
+
+	if *out == "" {
+		return
+	}
+	var formats []string
+	if *formatsStr != "" {
+		formats = strings.Split(*formatsStr, ",")
+	}
+	loc, ok := description.Locations.Preferred(formats...)
+	if !ok {
+		logrus.Fatal("asset has no locations to download")
+	}
+
+	logrus.WithField("format", loc.AssetFormat).Info("downloading")
+	if err := download(ctx, loc.Location, *out); err != nil {
+		logrus.WithError(err).Fatal("download asset")
+	}
+}
+
+// download writes the contents at location to the file at path, or to stdout if path is -.
+func download(ctx context.Context, location, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	dst := os.Stdout
+	if path != "-" {
+		if dst, err = os.Create(path); err != nil {
+			return err
+		}
+		defer dst.Close()
+	}
+
+	if _, err := io.Copy(dst, resp.Body); err != nil {
+		return err
+	}
+
+	return dst.Sync()
+}
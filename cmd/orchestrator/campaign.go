@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
+)
+
+// Campaign selects the assets that a run of the orchestrator downloads.
+// Ranges are logged per campaign, so that separate campaigns can sync the same range.
+type Campaign struct {
+	AssetTypes             []assetdelivery.AssetType
+	ExcludeAssetTypes      []assetdelivery.AssetType
+	SkipArchived           bool
+	SkipCopyrightProtected bool
+	// Filter is a predicate expression in the canonical form returned by assetdelivery.Predicate.String.
+	Filter string
+	// Versions downloads every version of the selected assets.
+	Versions bool
+	// Formats are the asset formats to download, most preferred first.
+	Formats []string
+	// AllFormats downloads every format of the selected assets.
+	AllFormats bool
+}
+
+// parseAssetTypes parses a comma-separated list of asset type names or IDs.
+func parseAssetTypes(s string) ([]assetdelivery.AssetType, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var types []assetdelivery.AssetType
+	for _, name := range strings.Split(s, ",") {
+		t, err := assetdelivery.ParseAssetType(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+
+	return types, nil
+}
+
+// parseList parses a comma-separated list of asset formats or TLS profiles, lowercased since they're compared
+// case-insensitively.
+func parseList(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var formats []string
+	for _, format := range strings.Split(s, ",") {
+		if format = strings.ToLower(strings.TrimSpace(format)); format != "" {
+			formats = append(formats, format)
+		}
+	}
+
+	return formats
+}
+
+// Key identifies c in the events table. Campaigns selecting the same assets have the same key,
+// and the default campaign, which only downloads models, has the empty key so that it matches
+// ranges logged before campaigns existed.
+func (c Campaign) Key() string {
+	var parts []string
+	if types := c.AssetTypes; len(types) > 0 && !(len(c.ExcludeAssetTypes) == 0 && sameTypes(types, client.DefaultAssetTypes)) {
+		parts = append(parts, "types="+joinTypes(types))
+	}
+	if len(c.ExcludeAssetTypes) > 0 {
+		parts = append(parts, "exclude="+joinTypes(c.ExcludeAssetTypes))
+	}
+	if c.SkipArchived {
+		parts = append(parts, "skip-archived")
+	}
+	if c.SkipCopyrightProtected {
+		parts = append(parts, "skip-copyright-protected")
+	}
+	if c.Filter != "" {
+		parts = append(parts, "filter="+c.Filter)
+	}
+	if c.Versions {
+		parts = append(parts, "versions")
+	}
+	if len(c.Formats) > 0 {
+		// in order, since the first format available is the one downloaded
+		parts = append(parts, "formats="+strings.Join(c.Formats, ","))
+	}
+	if c.AllFormats {
+		parts = append(parts, "all-formats")
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// validate checks that the key of c fits in the events table, where a longer one would fail every log of a range
+// only once jobs have run.
+func (c Campaign) validate() error {
+	if n := len(c.Key()); n > campaignWidth {
+		return fmt.Errorf("campaign key is %d bytes long, over the limit of %d; try a shorter filter", n, campaignWidth)
+	}
+
+	return nil
+}
+
+func (c Campaign) String() string {
+	if key := c.Key(); key != "" {
+		return key
+	}
+
+	return "default"
+}
+
+// apply sets the asset selection of req to c.
+func (c Campaign) apply(req *client.Request) {
+	req.AssetTypes = c.AssetTypes
+	req.ExcludeAssetTypes = c.ExcludeAssetTypes
+	req.SkipArchived = c.SkipArchived
+	req.SkipCopyrightProtected = c.SkipCopyrightProtected
+	req.Filter = c.Filter
+	req.Versions = c.Versions
+	req.Formats = c.Formats
+	req.AllFormats = c.AllFormats
+}
+
+// joinTypes returns the sorted, deduplicated IDs of types, joined by commas.
+func joinTypes(types []assetdelivery.AssetType) string {
+	ids := make([]int, 0, len(types))
+	seen := make(map[assetdelivery.AssetType]bool)
+	for _, t := range types {
+		if !seen[t] {
+			seen[t] = true
+			ids = append(ids, int(t))
+		}
+	}
+	sort.Ints(ids)
+
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = fmt.Sprint(id)
+	}
+
+	return strings.Join(strs, ",")
+}
+
+func sameTypes(a, b []assetdelivery.AssetType) bool {
+	return joinTypes(a) == joinTypes(b)
+}
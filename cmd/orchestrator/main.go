@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
 
@@ -19,20 +23,108 @@ import (
 )
 
 func main() {
+	orderStr := flag.String("order", "descending", "order in which to sync the range: descending, ascending, interleaved:<shards> or shuffled:<seed>")
+	flag.Int64Var(&ranges.Frontier, "frontier", ranges.Frontier, "highest asset ID that open-ended ranges such as 9B- extend to")
+	lookup := flag.Int64("lookup", 0, "instead of syncing, report which ID sets contain the given asset ID")
+	shardStr := flag.String("shard", "0/1", "shard i/n of the range to sync, so that n orchestrators can split a campaign between them")
+	typesStr := flag.String("types", "", "comma-separated asset types to download, by name or ID; defaults to Model unless -exclude-types is set")
+	excludeTypesStr := flag.String("exclude-types", "", "comma-separated asset types never to download")
+	skipArchived := flag.Bool("skip-archived", false, "skip archived assets")
+	skipCopyrightProtected := flag.Bool("skip-copyright-protected", false, "skip copyright protected assets")
+	filter := flag.String("filter", "", "predicate expression further restricting the assets to download, e.g. \"not archived and format:source\"")
+	versions := flag.Bool("versions", false, "download every version of the selected assets, and record them in the asset_versions table")
+	formatsStr := flag.String("formats", "", "comma-separated asset formats to download, most preferred first; assets in none of them are downloaded in their first format")
+	allFormats := flag.Bool("all-formats", false, "download every format that each asset is offered in")
+	tlsProfilesStr := flag.String("tls-profiles", "", "comma-separated TLS ClientHellos for the sync jobs to reach the Asset Delivery API with, e.g. chrome,firefox,safari; defaults to a randomized one")
+	tlsStrategy := flag.String("tls-strategy", "", "how sync jobs pick the TLS profile of each connection: fixed, round-robin or weighted")
+	proxyRotation := flag.String("proxy-rotation", "request", "how often sync jobs move on to their next proxy: request or session")
+	proxyRate := flag.Float64("proxy-rate", 0, "most requests per second that sync jobs send through each of their proxies, or 0 for no limit")
+	flag.Parse()
+
+	var campaign Campaign
+	var err error
+	if campaign.AssetTypes, err = parseAssetTypes(*typesStr); err != nil {
+		logrus.WithError(err).Fatal("parse asset types")
+	}
+	if campaign.ExcludeAssetTypes, err = parseAssetTypes(*excludeTypesStr); err != nil {
+		logrus.WithError(err).Fatal("parse excluded asset types")
+	}
+	campaign.SkipArchived = *skipArchived
+	campaign.SkipCopyrightProtected = *skipCopyrightProtected
+	campaign.Versions = *versions
+	campaign.Formats = parseList(*formatsStr)
+	campaign.AllFormats = *allFormats
+	if *filter != "" {
+		pred, err := assetdelivery.ParsePredicate(*filter)
+		if err != nil {
+			logrus.WithError(err).Fatal("parse filter")
+		}
+		campaign.Filter = pred.String()
+	}
+	if err := campaign.validate(); err != nil {
+		logrus.WithError(err).Fatal("invalid campaign")
+	}
+
+	shard, numShards, err := parseShard(*shardStr)
+	if err != nil {
+		logrus.WithError(err).WithField("shard", *shardStr).Fatal("invalid shard")
+	}
+
+	var order ranges.Order
+	if err := order.UnmarshalText([]byte(*orderStr)); err != nil {
+		logrus.WithError(err).Fatal("parse order")
+	}
+
 	store, err := NewSQL(os.Getenv("POSTGRES_CONN"))
 	if err != nil {
 		logrus.WithError(err).Fatal("create store")
 	}
 
+	if *lookup != 0 {
+		for _, kind := range []string{KindIndexed, KindFound, KindDownloaded, KindFailed} {
+			has, err := store.HasID(context.Background(), kind, *lookup)
+			if err != nil {
+				logrus.WithError(err).Fatal("look up ID")
+			}
+			fmt.Printf("%s\t%t\n", kind, has)
+		}
+		return
+	}
+
 	cl := client.NewClient()
 
-	rngsStr := os.Args[1]
-	var rng ranges.Range
-	if err := rng.UnmarshalText([]byte(rngsStr)); err != nil {
+	// how to reach the Asset Delivery API doesn't change which assets a campaign selects
+	template := client.Request{
+		TLSProfiles:   parseList(*tlsProfilesStr),
+		TLSStrategy:   *tlsStrategy,
+		ProxyRotation: *proxyRotation,
+		ProxyRate:     *proxyRate,
+	}
+
+	rngsStr := flag.Arg(0)
+	var rngs ranges.Ranges
+	if err := rngs.UnmarshalText([]byte(rngsStr)); err != nil {
 		logrus.WithError(err).Fatal("parse arg")
 	}
 
-	logrus.WithField("range", rngsStr).Info("starting job")
+	// shard before subtracting completed work, so that every orchestrator agrees on the partitions
+	rngs = rngs.Shard(shard, numShards)
+
+	completed, err := store.Completed(context.Background(), campaign.Key())
+	if err != nil {
+		logrus.WithError(err).Fatal("query completed ranges")
+	}
+	rngs = rngs.Subtract(completed)
+
+	logrus.WithFields(logrus.Fields{
+		"range":     rngsStr,
+		"shard":     *shardStr,
+		"remaining": rngs.Len(),
+		"order":     order,
+		"campaign":  campaign,
+	}).Info("starting job")
+
+	traversal := rngs.Traverse(order)
 
 	eg, eCtx := errgroup.WithContext(context.Background())
 	var mu sync.Mutex
@@ -48,46 +140,21 @@ func main() {
 					return nil
 				default:
 					mu.Lock()
-					subRng, more := pop(&rng, 2500)
+					chunk := traversal.Pop(2500)
 					mu.Unlock()
-					if !more {
+					if chunk.Len() == 0 {
 						return nil
 					}
 
-					logger := logrus.WithFields(logrus.Fields{
-						"range": subRng,
-						"index": i,
-					})
-
-					status, err := store.Query(eCtx, subRng)
-					if err != nil && !errors.Is(err, sql.ErrNoRows) {
-						logger.WithError(err).Error("couldn't query store")
-						continue
-					} else if status == http.StatusOK {
-						continue
-					}
-					// either ErrNoRows (no record) or the last one failed
-
-					if err := limiter.Wait(eCtx); err != nil {
-						return eCtx.Err()
-					}
-					logger.Info("kicking off job")
-					resp, err := cl.Sync(eCtx, client.Request{
-						Ranges: ranges.Ranges{subRng},
-					})
-					if err != nil {
-						logger.WithError(err).Error("couldn't request sync")
-						if strings.Contains(err.Error(), "Too Many Requests") {
-							if err := limiter.WaitN(eCtx, 2); err != nil {
-								return eCtx.Err()
-							}
+					// every job covers a single contiguous Range, so that it can be logged as one
+					for _, subRng := range chunk {
+						if subRng.Len() == 0 {
+							continue
 						}
-						continue
-					}
 
-					if err := store.Log(eCtx, subRng, resp); err != nil {
-						logger.WithError(err).Error("couldn't log response")
-						continue
+						if err := syncRange(eCtx, store, cl, limiter, campaign, template, subRng, i); err != nil {
+							return err
+						}
 					}
 				}
 			}
@@ -99,10 +166,73 @@ func main() {
 	}
 }
 
-func pop(rng *ranges.Range, n int) (ranges.Range, bool) {
-	if rng.Len() == 0 {
-		return ranges.Range{}, false
+// parseShard parses a shard i/n, with 0 <= i < n.
+func parseShard(s string) (shard, numShards int, err error) {
+	before, after, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, errors.New("want i/n")
+	}
+	if shard, err = strconv.Atoi(before); err != nil {
+		return 0, 0, err
+	}
+	if numShards, err = strconv.Atoi(after); err != nil {
+		return 0, 0, err
+	}
+	if numShards <= 0 || shard < 0 || shard >= numShards {
+		return 0, 0, fmt.Errorf("shard %d out of range for %d shards", shard, numShards)
+	}
+
+	return shard, numShards, nil
+}
+
+// syncRange kicks off a sync job for subRng unless it has already succeeded in the campaign, logging the result.
+// The job is template with the ranges and asset selection filled in.
+// Only a cancelled context is reported as an error; everything else is logged and skipped.
+func syncRange(ctx context.Context, store *SQL, cl *client.Client, limiter *rate.Limiter, campaign Campaign, template client.Request, subRng ranges.Range, i int) error {
+	logger := logrus.WithFields(logrus.Fields{
+		"range": subRng,
+		"index": i,
+	})
+
+	status, err := store.Query(ctx, campaign.Key(), subRng)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logger.WithError(err).Error("couldn't query store")
+		return nil
+	} else if status == http.StatusOK {
+		return nil
+	}
+	// either ErrNoRows (no record) or the last one failed
+
+	if err := limiter.Wait(ctx); err != nil {
+		return ctx.Err()
+	}
+	logger.Info("kicking off job")
+	req := template
+	req.Ranges = ranges.Ranges{subRng}
+	campaign.apply(&req)
+
+	resp, err := cl.Sync(ctx, req)
+	if err != nil {
+		logger.WithError(err).Error("couldn't request sync")
+		if errors.Is(err, client.ErrRateLimited) {
+			if err := limiter.WaitN(ctx, 2); err != nil {
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	if err := store.Log(ctx, campaign.Key(), subRng, resp); err != nil {
+		logger.WithError(err).Error("couldn't log response")
+	}
+
+	if err := store.LogIDs(ctx, resp); err != nil {
+		logger.WithError(err).Error("couldn't log IDs")
+	}
+
+	if err := store.LogVersions(ctx, resp.Versions); err != nil {
+		logger.WithError(err).Error("couldn't log versions")
 	}
 
-	return rng.Pop(n), true
+	return nil
 }
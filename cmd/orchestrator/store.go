@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -13,28 +15,89 @@ import (
 )
 
 type SQL struct {
-	db     *sql.DB
-	upsert *sql.Stmt
-	query  *sql.Stmt
+	db        *sql.DB
+	upsert    *sql.Stmt
+	query     *sql.Stmt
+	completed *sql.Stmt
+	bitmap    *sql.Stmt
 }
 
+// Kinds of ID sets kept in the id_bitmaps table.
+const (
+	KindIndexed    = "indexed"
+	KindFound      = "found"
+	KindDownloaded = "downloaded"
+	KindFailed     = "failed"
+)
+
+// bucketBits is the number of low ID bits covered by each row of the id_bitmaps table,
+// so that updating the bitmaps for a job only touches a few small rows.
+const bucketBits = 24
+
 const (
 	createTableStmt = `
 CREATE TABLE IF NOT EXISTS events (
-	range varchar(32),
+	range varchar(64),
 	status_code DOUBLE,
 	successes DOUBLE,
 	failures DOUBLE,
 	total DOUBLE,
 	duration_ms DOUBLE,
 	last_attempt_utc DOUBLE,
-	PRIMARY KEY (range)
+	campaign varchar(256) NOT NULL DEFAULT '',
+	PRIMARY KEY (range, campaign)
+);`
+
+	hasCampaignStmt = `SELECT count(*) FROM information_schema.columns WHERE table_name='events' AND column_name='campaign'`
+	rangeWidthStmt  = `SELECT character_maximum_length FROM information_schema.columns WHERE table_name='events' AND column_name='range'`
+
+	createBitmapsTableStmt = `
+CREATE TABLE IF NOT EXISTS id_bitmaps (
+	kind varchar(16),
+	bucket BIGINT,
+	bitmap BYTEA,
+	PRIMARY KEY (kind, bucket)
+);`
+
+	createVersionsTableStmt = `
+CREATE TABLE IF NOT EXISTS asset_versions (
+	asset_id BIGINT,
+	version INTEGER,
+	hash varchar(64),
+	stored BOOLEAN,
+	last_seen_utc BIGINT,
+	PRIMARY KEY (asset_id, version)
 );`
 
-	upsertStmt = `INSERT INTO events VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (range) DO UPDATE SET status_code=$2, successes=$3, failures=$4, total=$5, duration_ms=$6, last_attempt_utc=$7`
-	queryStmt  = `SELECT status_code FROM events WHERE range=$1`
+	upsertStmt    = `INSERT INTO events (range, campaign, status_code, successes, failures, total, duration_ms, last_attempt_utc) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (range, campaign) DO UPDATE SET status_code=$3, successes=$4, failures=$5, total=$6, duration_ms=$7, last_attempt_utc=$8`
+	queryStmt     = `SELECT status_code FROM events WHERE range=$1 AND campaign=$2`
+	completedStmt = `SELECT range FROM events WHERE status_code=200 AND campaign=$1`
+
+	selectBitmapStmt = `SELECT bitmap FROM id_bitmaps WHERE kind=$1 AND bucket=$2`
+	upsertBitmapStmt = `INSERT INTO id_bitmaps VALUES ($1, $2, $3) ON CONFLICT (kind, bucket) DO UPDATE SET bitmap=$3`
+
+	// a version stays stored once it has been, even if a later attempt to store it fails
+	upsertVersionStmt = `INSERT INTO asset_versions VALUES ($1, $2, $3, $4, $5) ON CONFLICT (asset_id, version) DO UPDATE SET hash=$3, stored=asset_versions.stored OR $4, last_seen_utc=$5`
 )
 
+// migrateCampaignStmts add the campaign column to an events table created before campaigns existed.
+// Existing ranges belong to the default campaign.
+var migrateCampaignStmts = []string{
+	`ALTER TABLE events ADD COLUMN campaign varchar(256) NOT NULL DEFAULT ''`,
+	`ALTER TABLE events DROP CONSTRAINT events_pkey`,
+	`ALTER TABLE events ADD PRIMARY KEY (range, campaign)`,
+}
+
+// campaignWidth is the width of the campaign column, which Campaign.Key must fit in.
+const campaignWidth = 256
+
+// rangeWidth is the width of the range column, enough for any Range: its bounds and step have at most 19 digits each.
+const rangeWidth = 64
+
+// widenRangeStmt widens the range column of an events table created when it was varchar(32), too narrow for
+// stepped ranges such as 9000000000-10000000000/1000000000.
+var widenRangeStmt = fmt.Sprintf(`ALTER TABLE events ALTER COLUMN range TYPE varchar(%d)`, rangeWidth)
+
 func NewSQL(address string) (*SQL, error) {
 	db, err := sql.Open("postgres", address)
 	if err != nil {
@@ -52,7 +115,19 @@ func NewSQL(address string) (*SQL, error) {
 		return nil, err
 	}
 
-	if err != nil {
+	if err = migrateCampaign(db); err != nil {
+		return nil, fmt.Errorf("add campaign column: %w", err)
+	}
+
+	if err = widenRange(db); err != nil {
+		return nil, fmt.Errorf("widen range column: %w", err)
+	}
+
+	if _, err = db.Exec(createBitmapsTableStmt); err != nil {
+		return nil, err
+	}
+
+	if _, err = db.Exec(createVersionsTableStmt); err != nil {
 		return nil, err
 	}
 
@@ -68,10 +143,49 @@ func NewSQL(address string) (*SQL, error) {
 		return nil, err
 	}
 
+	if s.completed, err = db.Prepare(completedStmt); err != nil {
+		return nil, err
+	}
+
+	if s.bitmap, err = db.Prepare(selectBitmapStmt); err != nil {
+		return nil, err
+	}
+
 	return &s, nil
 }
 
-func (s *SQL) Log(ctx context.Context, rng ranges.Range, resp *client.Response) error {
+func migrateCampaign(db *sql.DB) error {
+	var n int
+	if err := db.QueryRow(hasCampaignStmt).Scan(&n); err != nil || n > 0 {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range migrateCampaignStmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func widenRange(db *sql.DB) error {
+	var width sql.NullInt64
+	if err := db.QueryRow(rangeWidthStmt).Scan(&width); err != nil || !width.Valid || width.Int64 >= rangeWidth {
+		return err
+	}
+
+	_, err := db.Exec(widenRangeStmt)
+	return err
+}
+
+func (s *SQL) Log(ctx context.Context, campaign string, rng ranges.Range, resp *client.Response) error {
 	txt, err := rng.MarshalText()
 	if err != nil {
 		return err
@@ -80,6 +194,7 @@ func (s *SQL) Log(ctx context.Context, rng ranges.Range, resp *client.Response)
 	if _, err := s.upsert.ExecContext(
 		ctx,
 		txt,
+		campaign,
 		resp.StatusCode,
 		resp.Successes,
 		resp.Failures,
@@ -93,15 +208,223 @@ func (s *SQL) Log(ctx context.Context, rng ranges.Range, resp *client.Response)
 	return nil
 }
 
-func (s *SQL) Query(ctx context.Context, rng ranges.Range) (statusCode int, err error) {
+func (s *SQL) Query(ctx context.Context, campaign string, rng ranges.Range) (statusCode int, err error) {
 	txt, err := rng.MarshalText()
 	if err != nil {
 		return 0, err
 	}
 
-	if err := s.query.QueryRow(txt).Scan(&statusCode); err != nil {
+	if err := s.query.QueryRowContext(ctx, txt, campaign).Scan(&statusCode); err != nil {
 		return 0, err
 	}
 
 	return statusCode, nil
 }
+
+// Completed returns the normalized set of IDs covered by ranges successfully synced in the given campaign.
+func (s *SQL) Completed(ctx context.Context, campaign string) (ranges.Ranges, error) {
+	rows, err := s.completed.QueryContext(ctx, campaign)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var completed ranges.Ranges
+	for rows.Next() {
+		var txt string
+		if err := rows.Scan(&txt); err != nil {
+			return nil, err
+		}
+
+		var rng ranges.Range
+		if err := rng.UnmarshalText([]byte(txt)); err != nil {
+			return nil, err
+		}
+		completed = append(completed, rng)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return completed.Normalize(), nil
+}
+
+// LogIDs records the per-ID outcomes of a sync job in the id_bitmaps table.
+// The downloaded and failed sets don't overlap: IDs that were downloaded are cleared from the failed set, in case
+// an earlier attempt failed, and IDs that fail are only added to it if no attempt, earlier or not, downloaded them.
+func (s *SQL) LogIDs(ctx context.Context, resp *client.Response) error {
+	for kind, ids := range map[string]*ranges.Bitmap{
+		KindIndexed:    resp.Indexed,
+		KindFound:      resp.Found,
+		KindDownloaded: resp.Downloaded,
+	} {
+		if err := s.updateIDs(ctx, kind, ids, (*ranges.Bitmap).Or); err != nil {
+			return err
+		}
+	}
+
+	if err := s.updateIDs(ctx, KindFailed, resp.Downloaded, (*ranges.Bitmap).AndNot); err != nil {
+		return err
+	}
+
+	return s.addFailedIDs(ctx, resp.Failed)
+}
+
+// LogVersions records the asset versions found by a sync job in the asset_versions table.
+func (s *SQL) LogVersions(ctx context.Context, versions []client.AssetVersion) error {
+	if len(versions) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, upsertVersionStmt)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UnixMilli()
+	for _, v := range versions {
+		if _, err := stmt.ExecContext(ctx, v.AssetID, v.Version, v.Hash, v.Stored, now); err != nil {
+			return fmt.Errorf("log version %d of asset %d: %w", v.Version, v.AssetID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// HasID reports whether id is in the set of the given kind.
+func (s *SQL) HasID(ctx context.Context, kind string, id int64) (bool, error) {
+	var buf []byte
+	if err := s.bitmap.QueryRowContext(ctx, kind, id>>bucketBits).Scan(&buf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var stored ranges.Bitmap
+	if err := stored.UnmarshalBinary(buf); err != nil {
+		return false, err
+	}
+
+	return stored.Contains(id), nil
+}
+
+// updateIDs applies op to the stored set of the given kind and ids, bucket by bucket.
+func (s *SQL) updateIDs(ctx context.Context, kind string, ids *ranges.Bitmap, op func(stored, ids *ranges.Bitmap)) error {
+	if ids == nil {
+		return nil
+	}
+
+	for bucket, part := range buckets(ids) {
+		part := part
+		if err := s.updateBucket(ctx, kind, bucket, func(_ *sql.Tx, stored *ranges.Bitmap) error {
+			op(stored, part)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("update %s IDs in bucket %d: %w", kind, bucket, err)
+		}
+	}
+
+	return nil
+}
+
+// addFailedIDs adds ids to the failed set, except those in the downloaded set, bucket by bucket.
+func (s *SQL) addFailedIDs(ctx context.Context, ids *ranges.Bitmap) error {
+	if ids == nil {
+		return nil
+	}
+
+	for bucket, part := range buckets(ids) {
+		part := part
+		if err := s.updateBucket(ctx, KindFailed, bucket, func(tx *sql.Tx, stored *ranges.Bitmap) error {
+			downloaded, err := loadBucket(ctx, tx, selectBitmapStmt, KindDownloaded, bucket)
+			if err != nil {
+				return err
+			}
+			part.AndNot(downloaded)
+			stored.Or(part)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("update %s IDs in bucket %d: %w", KindFailed, bucket, err)
+		}
+	}
+
+	return nil
+}
+
+// updateBucket applies op to the stored set of the given kind in bucket, within the transaction that it passes op.
+func (s *SQL) updateBucket(ctx context.Context, kind string, bucket int64, op func(tx *sql.Tx, stored *ranges.Bitmap) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stored, err := loadBucket(ctx, tx, selectBitmapStmt+" FOR UPDATE", kind, bucket)
+	if err != nil {
+		return err
+	}
+
+	if err := op(tx, stored); err != nil {
+		return err
+	}
+	buf, err := stored.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, upsertBitmapStmt, kind, bucket, buf); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadBucket returns the stored set of the given kind in bucket, selected by query, which is empty if there is none.
+func loadBucket(ctx context.Context, tx *sql.Tx, query, kind string, bucket int64) (*ranges.Bitmap, error) {
+	var stored ranges.Bitmap
+	var buf []byte
+	if err := tx.QueryRowContext(ctx, query, kind, bucket).Scan(&buf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &stored, nil
+		}
+		return nil, err
+	}
+
+	if err := stored.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// buckets splits ids into one Bitmap per bucket of the id_bitmaps table.
+func buckets(ids *ranges.Bitmap) map[int64]*ranges.Bitmap {
+	parts := make(map[int64]*ranges.Bitmap)
+	for _, rng := range ids.Ranges() {
+		for start := rng.Start(); start < rng.End(); {
+			bucket := start >> bucketBits
+			end := (bucket + 1) << bucketBits
+			if end > rng.End() {
+				end = rng.End()
+			}
+
+			sub, _ := ranges.NewRange(start, end-1) // never invalid, since start < end
+			if parts[bucket] == nil {
+				parts[bucket] = &ranges.Bitmap{}
+			}
+			parts[bucket].AddRange(sub)
+			start = end
+		}
+	}
+
+	return parts
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/sampling"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// sampler estimates the number of assets of each type per region of a range, so that a campaign
+// can skip or deprioritize sparse regions before paying to sync them.
+func main() {
+	numRegions := flag.Int("regions", 100, "number of equally sized regions to split the range into")
+	perRegion := flag.Int64("sample", 2560, "number of IDs to sample per region")
+	seed := flag.Int64("seed", 1, "seed for drawing the samples")
+	confidence := flag.Float64("confidence", 0.95, "confidence level of the reported intervals")
+	assetTypeStr := flag.String("type", "", "if set, only report this asset type (e.g. Model or 10), and sort regions by its estimated count")
+	concurrency := flag.Int("concurrency", 8, "number of sampling jobs to run at once")
+	flag.Int64Var(&ranges.Frontier, "frontier", ranges.Frontier, "highest asset ID that open-ended ranges such as 9B- extend to")
+	flag.Parse()
+
+	var assetType assetdelivery.AssetType
+	if *assetTypeStr != "" {
+		var err error
+		if assetType, err = assetdelivery.ParseAssetType(*assetTypeStr); err != nil {
+			logrus.WithError(err).Fatal("parse asset type")
+		}
+	}
+
+	var rngs ranges.Ranges
+	if err := rngs.UnmarshalText([]byte(flag.Arg(0))); err != nil {
+		logrus.WithError(err).Fatal("parse arg")
+	}
+
+	plan := sampling.Plan(rngs, *numRegions, *perRegion, *seed)
+	tallies := make([]sampling.Tally, len(plan))
+
+	cl := client.NewClient()
+	limiter := rate.NewLimiter(rate.Every(time.Minute/360), 3)
+	eg, eCtx := errgroup.WithContext(context.Background())
+	jobs := make(chan int)
+
+	eg.Go(func() error {
+		defer close(jobs)
+		for i := range plan {
+			select {
+			case <-eCtx.Done():
+				return nil
+			case jobs <- i:
+			}
+		}
+		return nil
+	})
+
+	for w := 0; w < *concurrency; w++ {
+		eg.Go(func() error {
+			for i := range jobs {
+				if err := limiter.Wait(eCtx); err != nil {
+					return err
+				}
+
+				logger := logrus.WithFields(logrus.Fields{"region": plan[i].Ranges, "index": i})
+				logger.Info("sampling region")
+				resp, err := cl.Sync(eCtx, client.Request{
+					Ranges: plan[i].Sample,
+					Sample: true,
+				})
+				if err != nil {
+					logger.WithError(err).Error("couldn't sample region")
+					// reported as a region with nothing sampled, whose estimate is anywhere in its population
+					tallies[i].Failed = plan[i].Sample.Len()
+					continue
+				}
+				if resp.Samples != nil {
+					tallies[i] = *resp.Samples
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		logrus.WithError(err).Fatal("run samples")
+	}
+
+	report(plan, tallies, assetType, *confidence)
+}
+
+// row is a line of the report. Its assetType is zero for a region where no assets of any type were found.
+type row struct {
+	region     ranges.Ranges
+	population int64
+	sampled    int64
+	failed     int64
+	assetType  assetdelivery.AssetType
+	estimate   sampling.Estimate
+}
+
+// report prints a row per asset type found in each region, or only for assetType if it isn't zero.
+// Every region gets a row, so that those where nothing was found, or nothing could be sampled, show up too.
+func report(plan []sampling.Region, tallies []sampling.Tally, assetType assetdelivery.AssetType, confidence float64) {
+	var rows []row
+	for i, region := range plan {
+		types := []assetdelivery.AssetType{assetType}
+		if assetType == 0 && len(tallies[i].Counts) > 0 {
+			types = types[:0]
+			for t := range tallies[i].Counts {
+				types = append(types, t)
+			}
+			sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+		}
+
+		for _, t := range types {
+			rows = append(rows, row{
+				region:     region.Ranges,
+				population: region.Population(),
+				sampled:    tallies[i].Sampled,
+				failed:     tallies[i].Failed,
+				assetType:  t,
+				estimate:   sampling.EstimateCount(tallies[i].Counts[t], tallies[i].Sampled, region.Population(), confidence),
+			})
+		}
+	}
+
+	if assetType != 0 {
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].estimate.Count > rows[j].estimate.Count })
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "region\tpopulation\tsampled\tfailed\ttype\testimate\t%g%% low\t%g%% high\t\n", confidence*100, confidence*100)
+	for _, r := range rows {
+		region, _ := r.region.MarshalText()
+		typeName := "-"
+		if r.assetType != 0 {
+			typeName = r.assetType.String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%.0f\t%.0f\t%.0f\t\n",
+			region, r.population, r.sampled, r.failed, typeName, r.estimate.Count, r.estimate.Low, r.estimate.High)
+	}
+	w.Flush()
+}
@@ -3,6 +3,7 @@ module github.com/suremarc/go-rblx-asset-scraper
 go 1.18
 
 require (
+	github.com/go-resty/resty/v2 v2.7.0
 	github.com/lib/pq v1.10.6
 	github.com/sirupsen/logrus v1.9.0
 	github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync v0.0.0-20220805025539-742f871be101
@@ -10,4 +11,11 @@ require (
 	golang.org/x/time v0.0.0-20220722155302-e5dcc9cfc0b9
 )
 
-require golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+require (
+	github.com/josharian/intern v1.0.0 // indirect
+	github.com/mailru/easyjson v0.7.7 // indirect
+	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd // indirect
+	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
+)
+
+replace github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync => ./packages/scraper/sync
@@ -17,7 +17,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(in *jlexer.Lexer, out *Locations) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(in *jlexer.Lexer, out *contentRepresentationList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -26,15 +26,15 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Delim('[')
 		if *out == nil {
 			if !in.IsDelim(']') {
-				*out = make(Locations, 0, 2)
+				*out = make(contentRepresentationList, 0, 1)
 			} else {
-				*out = Locations{}
+				*out = contentRepresentationList{}
 			}
 		} else {
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v1 Location
+			var v1 ContentRepresentation
 			(v1).UnmarshalEasyJSON(in)
 			*out = append(*out, v1)
 			in.WantComma()
@@ -45,7 +45,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(out *jwriter.Writer, in Locations) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(out *jwriter.Writer, in contentRepresentationList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
@@ -61,29 +61,95 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Locations) MarshalJSON() ([]byte, error) {
+func (v contentRepresentationList) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
 	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Locations) MarshalEasyJSON(w *jwriter.Writer) {
+func (v contentRepresentationList) MarshalEasyJSON(w *jwriter.Writer) {
 	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Locations) UnmarshalJSON(data []byte) error {
+func (v *contentRepresentationList) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
 	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Locations) UnmarshalEasyJSON(l *jlexer.Lexer) {
+func (v *contentRepresentationList) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(in *jlexer.Lexer, out *Location) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(in *jlexer.Lexer, out *Locations) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(Locations, 0, 2)
+			} else {
+				*out = Locations{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v4 Location
+			(v4).UnmarshalEasyJSON(in)
+			*out = append(*out, v4)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(out *jwriter.Writer, in Locations) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v5, v6 := range in {
+			if v5 > 0 {
+				out.RawByte(',')
+			}
+			(v6).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Locations) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Locations) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Locations) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Locations) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(l, v)
+}
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(in *jlexer.Lexer, out *Location) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -116,7 +182,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(out *jwriter.Writer, in Location) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(out *jwriter.Writer, in Location) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -136,27 +202,27 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 // MarshalJSON supports json.Marshaler interface
 func (v Location) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Location) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Location) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Location) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery1(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(in *jlexer.Lexer, out *ErrorsResponse) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(in *jlexer.Lexer, out *ErrorsResponse) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -189,7 +255,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(out *jwriter.Writer, in ErrorsResponse) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(out *jwriter.Writer, in ErrorsResponse) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -209,27 +275,27 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 // MarshalJSON supports json.Marshaler interface
 func (v ErrorsResponse) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v ErrorsResponse) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *ErrorsResponse) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *ErrorsResponse) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery2(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(in *jlexer.Lexer, out *Errors) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(in *jlexer.Lexer, out *Errors) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -246,9 +312,9 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v4 Error
-			(v4).UnmarshalEasyJSON(in)
-			*out = append(*out, v4)
+			var v7 Error
+			(v7).UnmarshalEasyJSON(in)
+			*out = append(*out, v7)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -257,16 +323,16 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(out *jwriter.Writer, in Errors) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(out *jwriter.Writer, in Errors) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v5, v6 := range in {
-			if v5 > 0 {
+		for v8, v9 := range in {
+			if v8 > 0 {
 				out.RawByte(',')
 			}
-			(v6).MarshalEasyJSON(out)
+			(v9).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -275,27 +341,27 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 // MarshalJSON supports json.Marshaler interface
 func (v Errors) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Errors) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Errors) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Errors) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery3(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(in *jlexer.Lexer, out *Error) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(in *jlexer.Lexer, out *Error) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -330,7 +396,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(out *jwriter.Writer, in Error) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(out *jwriter.Writer, in Error) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -355,27 +421,107 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 // MarshalJSON supports json.Marshaler interface
 func (v Error) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Error) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Error) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Error) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery4(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(l, v)
+}
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(in *jlexer.Lexer, out *ContentRepresentation) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "format":
+			out.Format = string(in.String())
+		case "majorVersion":
+			out.MajorVersion = string(in.String())
+		case "fidelity":
+			out.Fidelity = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(out *jwriter.Writer, in ContentRepresentation) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"format\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Format))
+	}
+	{
+		const prefix string = ",\"majorVersion\":"
+		out.RawString(prefix)
+		out.String(string(in.MajorVersion))
+	}
+	if in.Fidelity != "" {
+		const prefix string = ",\"fidelity\":"
+		out.RawString(prefix)
+		out.String(string(in.Fidelity))
+	}
+	out.RawByte('}')
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(in *jlexer.Lexer, out *AssetRequestItems) {
+
+// MarshalJSON supports json.Marshaler interface
+func (v ContentRepresentation) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ContentRepresentation) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ContentRepresentation) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ContentRepresentation) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(l, v)
+}
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(in *jlexer.Lexer, out *AssetRequestItems) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -384,7 +530,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Delim('[')
 		if *out == nil {
 			if !in.IsDelim(']') {
-				*out = make(AssetRequestItems, 0, 2)
+				*out = make(AssetRequestItems, 0, 0)
 			} else {
 				*out = AssetRequestItems{}
 			}
@@ -392,9 +538,9 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v7 AssetRequestItem
-			(v7).UnmarshalEasyJSON(in)
-			*out = append(*out, v7)
+			var v10 AssetRequestItem
+			(v10).UnmarshalEasyJSON(in)
+			*out = append(*out, v10)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -403,16 +549,16 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(out *jwriter.Writer, in AssetRequestItems) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(out *jwriter.Writer, in AssetRequestItems) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v8, v9 := range in {
-			if v8 > 0 {
+		for v11, v12 := range in {
+			if v11 > 0 {
 				out.RawByte(',')
 			}
-			(v9).MarshalEasyJSON(out)
+			(v12).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -421,27 +567,27 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 // MarshalJSON supports json.Marshaler interface
 func (v AssetRequestItems) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v AssetRequestItems) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *AssetRequestItems) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *AssetRequestItems) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery5(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(in *jlexer.Lexer, out *AssetRequestItem) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(in *jlexer.Lexer, out *AssetRequestItem) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -464,6 +610,22 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 			out.RequestID = string(in.String())
 		case "assetId":
 			out.AssetID = int64(in.Int64())
+		case "version":
+			out.Version = int(in.Int())
+		case "assetVersionId":
+			out.AssetVersionID = int64(in.Int64())
+		case "assetType":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.AssetType).UnmarshalJSON(data))
+			}
+		case "accept":
+			out.Accept = string(in.String())
+		case "contentRepresentationPriorityList":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.ContentRepresentations).UnmarshalJSON(data))
+			}
+		case "doNotFallbackToBaselineRepresentation":
+			out.DoNotFallbackToBaselineRepresentation = bool(in.Bool())
 		default:
 			in.SkipRecursive()
 		}
@@ -474,7 +636,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(out *jwriter.Writer, in AssetRequestItem) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(out *jwriter.Writer, in AssetRequestItem) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -483,38 +645,68 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		out.RawString(prefix[1:])
 		out.String(string(in.RequestID))
 	}
-	{
+	if in.AssetID != 0 {
 		const prefix string = ",\"assetId\":"
 		out.RawString(prefix)
 		out.Int64(int64(in.AssetID))
 	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
+	if in.AssetVersionID != 0 {
+		const prefix string = ",\"assetVersionId\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.AssetVersionID))
+	}
+	if in.AssetType != 0 {
+		const prefix string = ",\"assetType\":"
+		out.RawString(prefix)
+		out.Raw((in.AssetType).MarshalJSON())
+	}
+	if in.Accept != "" {
+		const prefix string = ",\"accept\":"
+		out.RawString(prefix)
+		out.String(string(in.Accept))
+	}
+	if len(in.ContentRepresentations) != 0 {
+		const prefix string = ",\"contentRepresentationPriorityList\":"
+		out.RawString(prefix)
+		out.Raw((in.ContentRepresentations).MarshalJSON())
+	}
+	if in.DoNotFallbackToBaselineRepresentation {
+		const prefix string = ",\"doNotFallbackToBaselineRepresentation\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.DoNotFallbackToBaselineRepresentation))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v AssetRequestItem) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v AssetRequestItem) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *AssetRequestItem) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *AssetRequestItem) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery6(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(in *jlexer.Lexer, out *AssetDescriptions) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery9(in *jlexer.Lexer, out *AssetDescriptions) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -531,9 +723,9 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v10 AssetDescription
-			(v10).UnmarshalEasyJSON(in)
-			*out = append(*out, v10)
+			var v13 AssetDescription
+			(v13).UnmarshalEasyJSON(in)
+			*out = append(*out, v13)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -542,16 +734,16 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(out *jwriter.Writer, in AssetDescriptions) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery9(out *jwriter.Writer, in AssetDescriptions) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v11, v12 := range in {
-			if v11 > 0 {
+		for v14, v15 := range in {
+			if v14 > 0 {
 				out.RawByte(',')
 			}
-			(v12).MarshalEasyJSON(out)
+			(v15).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -560,27 +752,27 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 // MarshalJSON supports json.Marshaler interface
 func (v AssetDescriptions) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery9(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v AssetDescriptions) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery9(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *AssetDescriptions) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery9(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *AssetDescriptions) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery7(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery9(l, v)
 }
-func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(in *jlexer.Lexer, out *AssetDescription) {
+func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery10(in *jlexer.Lexer, out *AssetDescription) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -605,16 +797,22 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 			(out.Errors).UnmarshalEasyJSON(in)
 		case "requestId":
 			out.RequestID = string(in.String())
-		case "IsHashDynamic":
+		case "isHashDynamic":
 			out.IsHashDynamic = bool(in.Bool())
-		case "IsCopyrightProtected":
+		case "isCopyrightProtected":
 			out.IsCopyrightProtected = bool(in.Bool())
 		case "isArchived":
 			out.IsArchived = bool(in.Bool())
 		case "assetTypeId":
-			out.AssetTypeID = int(in.Int())
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.AssetTypeID).UnmarshalJSON(data))
+			}
 		case "assetId":
 			out.AssetID = int64(in.Int64())
+		case "version":
+			out.Version = int(in.Int())
+		case "assetVersionId":
+			out.AssetVersionID = int64(in.Int64())
 		default:
 			in.SkipRecursive()
 		}
@@ -625,7 +823,7 @@ func easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		in.Consumed()
 	}
 }
-func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(out *jwriter.Writer, in AssetDescription) {
+func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery10(out *jwriter.Writer, in AssetDescription) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -645,12 +843,12 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 		out.String(string(in.RequestID))
 	}
 	{
-		const prefix string = ",\"IsHashDynamic\":"
+		const prefix string = ",\"isHashDynamic\":"
 		out.RawString(prefix)
 		out.Bool(bool(in.IsHashDynamic))
 	}
 	{
-		const prefix string = ",\"IsCopyrightProtected\":"
+		const prefix string = ",\"isCopyrightProtected\":"
 		out.RawString(prefix)
 		out.Bool(bool(in.IsCopyrightProtected))
 	}
@@ -662,36 +860,46 @@ func easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyn
 	{
 		const prefix string = ",\"assetTypeId\":"
 		out.RawString(prefix)
-		out.Int(int(in.AssetTypeID))
+		out.Raw((in.AssetTypeID).MarshalJSON())
 	}
 	if in.AssetID != 0 {
 		const prefix string = ",\"assetId\":"
 		out.RawString(prefix)
 		out.Int64(int64(in.AssetID))
 	}
+	if in.Version != 0 {
+		const prefix string = ",\"version\":"
+		out.RawString(prefix)
+		out.Int(int(in.Version))
+	}
+	if in.AssetVersionID != 0 {
+		const prefix string = ",\"assetVersionId\":"
+		out.RawString(prefix)
+		out.Int64(int64(in.AssetVersionID))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v AssetDescription) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(&w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery10(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v AssetDescription) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(w, v)
+	easyjsonB54af022EncodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery10(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *AssetDescription) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(&r, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery10(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *AssetDescription) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery8(l, v)
+	easyjsonB54af022DecodeGithubComSuremarcGoRblxAssetScraperPackagesScraperSyncAssetdelivery10(l, v)
 }
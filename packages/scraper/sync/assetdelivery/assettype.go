@@ -0,0 +1,237 @@
+package assetdelivery
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AssetType is a Roblox asset type ID, as published in the AssetType enum.
+type AssetType int
+
+const (
+	Image                    AssetType = 1
+	TShirt                   AssetType = 2
+	Audio                    AssetType = 3
+	Mesh                     AssetType = 4
+	Lua                      AssetType = 5
+	Hat                      AssetType = 8
+	Place                    AssetType = 9
+	Model                    AssetType = 10
+	Shirt                    AssetType = 11
+	Pants                    AssetType = 12
+	Decal                    AssetType = 13
+	Head                     AssetType = 17
+	Face                     AssetType = 18
+	Gear                     AssetType = 19
+	Badge                    AssetType = 21
+	Animation                AssetType = 24
+	Torso                    AssetType = 27
+	RightArm                 AssetType = 28
+	LeftArm                  AssetType = 29
+	LeftLeg                  AssetType = 30
+	RightLeg                 AssetType = 31
+	Package                  AssetType = 32
+	GamePass                 AssetType = 34
+	Plugin                   AssetType = 38
+	MeshPart                 AssetType = 40
+	HairAccessory            AssetType = 41
+	FaceAccessory            AssetType = 42
+	NeckAccessory            AssetType = 43
+	ShoulderAccessory        AssetType = 44
+	FrontAccessory           AssetType = 45
+	BackAccessory            AssetType = 46
+	WaistAccessory           AssetType = 47
+	ClimbAnimation           AssetType = 48
+	DeathAnimation           AssetType = 49
+	FallAnimation            AssetType = 50
+	IdleAnimation            AssetType = 51
+	JumpAnimation            AssetType = 52
+	RunAnimation             AssetType = 53
+	SwimAnimation            AssetType = 54
+	WalkAnimation            AssetType = 55
+	PoseAnimation            AssetType = 56
+	EarAccessory             AssetType = 57
+	EyeAccessory             AssetType = 58
+	EmoteAnimation           AssetType = 61
+	Video                    AssetType = 62
+	TexturePack              AssetType = 63
+	TShirtAccessory          AssetType = 64
+	ShirtAccessory           AssetType = 65
+	PantsAccessory           AssetType = 66
+	JacketAccessory          AssetType = 67
+	SweaterAccessory         AssetType = 68
+	ShortsAccessory          AssetType = 69
+	LeftShoeAccessory        AssetType = 70
+	RightShoeAccessory       AssetType = 71
+	DressSkirtAccessory      AssetType = 72
+	FontFamily               AssetType = 73
+	FontFace                 AssetType = 74
+	MeshHiddenSurfaceRemoval AssetType = 75
+	EyebrowAccessory         AssetType = 76
+	EyelashAccessory         AssetType = 77
+	MoodAnimation            AssetType = 78
+	DynamicHead              AssetType = 79
+)
+
+var assetTypeNames = map[AssetType]string{
+	Image:                    "Image",
+	TShirt:                   "TShirt",
+	Audio:                    "Audio",
+	Mesh:                     "Mesh",
+	Lua:                      "Lua",
+	Hat:                      "Hat",
+	Place:                    "Place",
+	Model:                    "Model",
+	Shirt:                    "Shirt",
+	Pants:                    "Pants",
+	Decal:                    "Decal",
+	Head:                     "Head",
+	Face:                     "Face",
+	Gear:                     "Gear",
+	Badge:                    "Badge",
+	Animation:                "Animation",
+	Torso:                    "Torso",
+	RightArm:                 "RightArm",
+	LeftArm:                  "LeftArm",
+	LeftLeg:                  "LeftLeg",
+	RightLeg:                 "RightLeg",
+	Package:                  "Package",
+	GamePass:                 "GamePass",
+	Plugin:                   "Plugin",
+	MeshPart:                 "MeshPart",
+	HairAccessory:            "HairAccessory",
+	FaceAccessory:            "FaceAccessory",
+	NeckAccessory:            "NeckAccessory",
+	ShoulderAccessory:        "ShoulderAccessory",
+	FrontAccessory:           "FrontAccessory",
+	BackAccessory:            "BackAccessory",
+	WaistAccessory:           "WaistAccessory",
+	ClimbAnimation:           "ClimbAnimation",
+	DeathAnimation:           "DeathAnimation",
+	FallAnimation:            "FallAnimation",
+	IdleAnimation:            "IdleAnimation",
+	JumpAnimation:            "JumpAnimation",
+	RunAnimation:             "RunAnimation",
+	SwimAnimation:            "SwimAnimation",
+	WalkAnimation:            "WalkAnimation",
+	PoseAnimation:            "PoseAnimation",
+	EarAccessory:             "EarAccessory",
+	EyeAccessory:             "EyeAccessory",
+	EmoteAnimation:           "EmoteAnimation",
+	Video:                    "Video",
+	TexturePack:              "TexturePack",
+	TShirtAccessory:          "TShirtAccessory",
+	ShirtAccessory:           "ShirtAccessory",
+	PantsAccessory:           "PantsAccessory",
+	JacketAccessory:          "JacketAccessory",
+	SweaterAccessory:         "SweaterAccessory",
+	ShortsAccessory:          "ShortsAccessory",
+	LeftShoeAccessory:        "LeftShoeAccessory",
+	RightShoeAccessory:       "RightShoeAccessory",
+	DressSkirtAccessory:      "DressSkirtAccessory",
+	FontFamily:               "FontFamily",
+	FontFace:                 "FontFace",
+	MeshHiddenSurfaceRemoval: "MeshHiddenSurfaceRemoval",
+	EyebrowAccessory:         "EyebrowAccessory",
+	EyelashAccessory:         "EyelashAccessory",
+	MoodAnimation:            "MoodAnimation",
+	DynamicHead:              "DynamicHead",
+}
+
+// assetTypesByName maps lowercased names to asset types, for case-insensitive parsing.
+var assetTypesByName = func() map[string]AssetType {
+	byName := make(map[string]AssetType, len(assetTypeNames))
+	for t, name := range assetTypeNames {
+		byName[strings.ToLower(name)] = t
+	}
+	return byName
+}()
+
+// ParseAssetType parses an asset type from its name, in any case, or from its numeric ID.
+// Numeric IDs without a name are accepted, so that new asset types don't need a code change.
+func ParseAssetType(s string) (AssetType, error) {
+	if t, ok := assetTypesByName[strings.ToLower(s)]; ok {
+		return t, nil
+	}
+
+	if id, err := strconv.Atoi(s); err == nil && id > 0 {
+		return AssetType(id), nil
+	}
+
+	return 0, fmt.Errorf("unknown asset type: %q", s)
+}
+
+// String returns the name of t, or AssetType(<id>) if it has none.
+func (t AssetType) String() string {
+	if name, ok := assetTypeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("AssetType(%d)", int(t))
+}
+
+var _ encoding.TextUnmarshaler = new(AssetType)
+var _ encoding.TextMarshaler = AssetType(0)
+var _ json.Unmarshaler = new(AssetType)
+var _ json.Marshaler = AssetType(0)
+
+// MarshalText returns the name of t, or its numeric ID if it has none.
+func (t AssetType) MarshalText() ([]byte, error) {
+	if name, ok := assetTypeNames[t]; ok {
+		return []byte(name), nil
+	}
+
+	return []byte(strconv.Itoa(int(t))), nil
+}
+
+func (t *AssetType) UnmarshalText(text []byte) error {
+	parsed, err := ParseAssetType(string(text))
+	if err != nil {
+		return err
+	}
+
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON encodes t as its numeric ID, as the Asset Delivery API does, so that JSON written by this package
+// stays readable by anything else that speaks the API. Names are left to human-facing text, from MarshalText.
+func (t AssetType) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Itoa(int(t))), nil
+}
+
+// UnmarshalJSON accepts either a numeric ID, as sent by the Asset Delivery API, or a name.
+func (t *AssetType) UnmarshalJSON(data []byte) error {
+	var id int
+	if err := json.Unmarshal(data, &id); err == nil {
+		*t = AssetType(id)
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("asset type must be a number or a string: %s", data)
+	}
+
+	return t.UnmarshalText([]byte(name))
+}
+
+// AssetTypeName is an AssetType that is encoded in JSON as its name, the way the batch endpoint takes the
+// asset type of a request item.
+type AssetTypeName AssetType
+
+func (t AssetTypeName) MarshalJSON() ([]byte, error) {
+	text, err := AssetType(t).MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+func (t *AssetTypeName) UnmarshalJSON(data []byte) error {
+	return (*AssetType)(t).UnmarshalJSON(data)
+}
@@ -0,0 +1,66 @@
+package assetdelivery
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetType(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		for text, expected := range map[string]AssetType{
+			"Model":    Model,
+			"audio":    Audio,
+			"MESHPART": MeshPart,
+			"13":       Decal,
+			"999":      AssetType(999),
+		} {
+			parsed, err := ParseAssetType(text)
+			require.NoError(t, err, text)
+			assert.Equal(t, expected, parsed, text)
+		}
+
+		for _, text := range []string{"", "Modle", "-1", "0"} {
+			_, err := ParseAssetType(text)
+			assert.Error(t, err, text)
+		}
+	})
+
+	t.Run("names", func(t *testing.T) {
+		assert.Equal(t, "Model", Model.String())
+		assert.Equal(t, "AssetType(999)", AssetType(999).String())
+		for assetType, name := range assetTypeNames {
+			parsed, err := ParseAssetType(name)
+			require.NoError(t, err)
+			assert.Equal(t, assetType, parsed)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var description AssetDescription
+		require.NoError(t, description.UnmarshalJSON([]byte(`{"assetTypeId":10,"locations":[]}`)))
+		assert.Equal(t, Model, description.AssetTypeID)
+
+		buf, err := description.MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(buf), `"assetTypeId":10`, "the wire format stays numeric")
+
+		// map keys are text, so they keep their names
+		buf, err = json.Marshal(map[AssetType][]AssetType{Audio: {Mesh, AssetType(999)}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"Audio":[4,999]}`, string(buf))
+
+		var decoded []AssetType
+		require.NoError(t, json.Unmarshal([]byte(`["Mesh",999,"decal",4]`), &decoded))
+		assert.Equal(t, []AssetType{Mesh, 999, Decal, Mesh}, decoded)
+		assert.Error(t, json.Unmarshal([]byte(`[true]`), &decoded))
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		descriptions := AssetDescriptions{{AssetID: 1, AssetTypeID: Model}, {AssetID: 2, AssetTypeID: Audio}, {AssetID: 3, AssetTypeID: Decal}}
+		assert.Equal(t, AssetDescriptions{descriptions[0], descriptions[2]}, descriptions.FilterByAssetTypes(Decal, Model))
+		assert.Empty(t, descriptions.FilterByAssetTypes())
+	})
+}
@@ -0,0 +1,145 @@
+package assetdelivery
+
+import (
+	"context"
+	"sync"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"golang.org/x/time/rate"
+)
+
+// MaxBatchSize is the most IDs the Asset Delivery API accepts in one batch request.
+const MaxBatchSize = 256
+
+// BatchAllOptions configures BatchAll, BatchIDs and Versions.
+type BatchAllOptions struct {
+	BatchOptions
+	// Order is the order in which BatchAll visits the IDs. It defaults to descending.
+	Order ranges.Order
+	// Workers is the most batch requests in flight at once. It defaults to 4.
+	Workers int
+	// Limiter, if non-nil, is waited on before each batch request. It may be shared with other calls.
+	Limiter *rate.Limiter
+	// MaxVersions is the most versions of an asset that Versions looks up. It defaults to DefaultMaxVersions.
+	MaxVersions int
+}
+
+// BatchResult is the outcome of one of the batch requests made by BatchAll or BatchIDs.
+type BatchResult struct {
+	// Ranges holds the IDs of the batch.
+	Ranges ranges.Ranges
+	// IDs holds the IDs of a batch from BatchIDs, in the order they were requested. Batches from BatchAll
+	// leave it nil: their IDs are expanded from Ranges into a buffer that each worker reuses, in the order
+	// of Descriptions.
+	IDs          []int64
+	Descriptions AssetDescriptions
+	Stats        CallStats
+	// Err is the error of the batch request, if it failed. A failed batch doesn't stop the others.
+	Err error
+}
+
+// BatchAll looks up the descriptions of every ID in rngs, in batches of at most MaxBatchSize IDs.
+// The results are sent on the returned channel as they arrive, which is closed once every batch is done,
+// or once ctx is done, in which case the batches still pending are dropped. The caller must either drain
+// the channel or cancel ctx.
+func (c *Client) BatchAll(ctx context.Context, rngs ranges.Ranges, opts *BatchAllOptions) <-chan BatchResult {
+	batches := make(chan BatchResult)
+	go func() {
+		defer close(batches)
+
+		traversal := rngs.Traverse(opts.Order)
+		for {
+			rng := traversal.Pop(MaxBatchSize)
+			if rng.Len() == 0 {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case batches <- BatchResult{Ranges: rng}:
+			}
+		}
+	}()
+
+	return c.runBatches(ctx, batches, opts)
+}
+
+// BatchIDs looks up the descriptions of ids like BatchAll, in batches of consecutive elements of ids.
+func (c *Client) BatchIDs(ctx context.Context, ids []int64, opts *BatchAllOptions) <-chan BatchResult {
+	batches := make(chan BatchResult)
+	go func() {
+		defer close(batches)
+
+		for len(ids) > 0 {
+			n := MaxBatchSize
+			if n > len(ids) {
+				n = len(ids)
+			}
+
+			var set ranges.Bitmap
+			for _, id := range ids[:n] {
+				set.Add(id)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case batches <- BatchResult{Ranges: set.Ranges(), IDs: ids[:n:n]}:
+			}
+			ids = ids[n:]
+		}
+	}()
+
+	return c.runBatches(ctx, batches, opts)
+}
+
+// runBatches requests the batches received from pending on a pool of workers, and sends them on with their results.
+func (c *Client) runBatches(ctx context.Context, pending <-chan BatchResult, opts *BatchAllOptions) <-chan BatchResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	results := make(chan BatchResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]int64, 0, MaxBatchSize)
+			for batch := range pending {
+				if opts.Limiter != nil {
+					if err := opts.Limiter.Wait(ctx); err != nil {
+						return
+					}
+				}
+
+				batchOpts := opts.BatchOptions
+				batchOpts.Stats = &batch.Stats
+				ids := batch.IDs
+				if ids == nil {
+					buf = batch.Ranges.AppendIDs(buf[:0])
+					ids = buf
+				}
+				batch.Descriptions, batch.Err = c.Batch(ctx, ids, &batchOpts)
+				if ctx.Err() != nil {
+					return
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case results <- batch:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
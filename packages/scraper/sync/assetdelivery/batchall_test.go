@@ -0,0 +1,104 @@
+package assetdelivery_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"golang.org/x/time/rate"
+)
+
+func TestBatchAll(t *testing.T) {
+	rng, err := ranges.NewRange(1, 1000)
+	require.NoError(t, err)
+	rngs := ranges.Ranges{rng}
+	opts := &assetdelivery.BatchAllOptions{
+		Workers: 3,
+		Limiter: rate.NewLimiter(rate.Inf, 1),
+	}
+
+	t.Run("ranges", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		var seen ranges.Ranges
+		var found int
+		for batch := range s.AssetDeliveryClient().BatchAll(context.Background(), rngs, opts) {
+			require.NoError(t, batch.Err)
+			assert.Nil(t, batch.IDs)
+			ids := batch.Ranges.AsIntSlice()
+			assert.LessOrEqual(t, len(ids), assetdelivery.MaxBatchSize)
+			require.Len(t, batch.Descriptions, len(ids))
+			for i, description := range batch.Descriptions {
+				assert.Equal(t, ids[i], description.AssetID)
+			}
+
+			assert.False(t, seen.Overlaps(batch.Ranges))
+			seen = seen.Union(batch.Ranges)
+			found += len(batch.Descriptions.DiscardErrored())
+		}
+
+		assert.Equal(t, rngs, seen)
+		assert.Equal(t, 333, found)
+		assert.Equal(t, 4, s.Requests())
+	})
+
+	t.Run("failures don't stop other batches", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		s.Fail(http.StatusForbidden, 1)
+
+		var failed, succeeded int
+		for batch := range s.AssetDeliveryClient().BatchAll(context.Background(), rngs, opts) {
+			if batch.Err != nil {
+				assert.ErrorIs(t, batch.Err, assetdelivery.ErrForbidden)
+				failed++
+			} else {
+				succeeded++
+			}
+		}
+
+		assert.Equal(t, 1, failed)
+		assert.Equal(t, 3, succeeded)
+	})
+
+	t.Run("ids", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		ids := make([]int64, 600)
+		for i := range ids {
+			ids[i] = int64(600-i) * 7
+		}
+
+		var got []int64
+		for batch := range s.AssetDeliveryClient().BatchIDs(context.Background(), ids, opts) {
+			require.NoError(t, batch.Err)
+			assert.Equal(t, batch.Ranges.Len(), int64(len(batch.IDs)))
+			got = append(got, batch.IDs...)
+		}
+
+		assert.ElementsMatch(t, ids, got)
+		assert.Equal(t, 3, s.Requests())
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		batches := s.AssetDeliveryClient().BatchAll(ctx, rngs, opts)
+		<-batches
+		cancel()
+
+		// the channel is closed without draining the rest
+		for range batches {
+		}
+		assert.LessOrEqual(t, s.Requests(), 4)
+	})
+}
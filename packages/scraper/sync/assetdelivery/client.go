@@ -3,86 +3,263 @@ package assetdelivery
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/go-resty/resty/v2"
 )
 
+// DefaultBaseURL is the endpoint of the real Asset Delivery API.
+const DefaultBaseURL = "https://assetdelivery.roblox.com"
+
 type Client struct {
-	client *resty.Client
+	client      *resty.Client
+	baseURL     string
+	retry       RetryPolicy
+	credentials CredentialsProvider
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the Client at another Asset Delivery API endpoint, such as a fake one in tests.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
 }
 
-func NewClient(r *resty.Client) *Client {
-	return &Client{
-		client: r,
+func NewClient(r *resty.Client, opts ...Option) *Client {
+	c := &Client{
+		client:  r,
+		baseURL: DefaultBaseURL,
+		retry:   DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
+}
+
+// BaseURL returns the endpoint that c sends requests to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
 }
 
 type BatchOptions struct {
 	SkipSigningScripts bool
+	// Stats, if non-nil, is filled in with the attempts made by the call.
+	Stats *CallStats
+
+	// Version, AssetType, Accept, ContentRepresentations and DoNotFallbackToBaselineRepresentation are sent
+	// with every item that doesn't set its own, as described on AssetRequestItem.
+	Version                               int
+	AssetType                             AssetType
+	Accept                                string
+	ContentRepresentations                ContentRepresentations
+	DoNotFallbackToBaselineRepresentation bool
 }
 
+// apply fills in the fields of item left unset from o, and gives it a request ID if it has none.
+func (o *BatchOptions) apply(item AssetRequestItem) AssetRequestItem {
+	if item.Version == 0 && item.AssetVersionID == 0 {
+		item.Version = o.Version
+	}
+	if item.AssetType == 0 {
+		item.AssetType = AssetTypeName(o.AssetType)
+	}
+	if item.Accept == "" {
+		item.Accept = o.Accept
+	}
+	if item.ContentRepresentations == nil {
+		item.ContentRepresentations = o.ContentRepresentations
+	}
+	item.DoNotFallbackToBaselineRepresentation = item.DoNotFallbackToBaselineRepresentation || o.DoNotFallbackToBaselineRepresentation
+	if item.RequestID == "" {
+		item.RequestID = item.defaultRequestID()
+	}
+
+	return item
+}
+
+// Batch looks up the descriptions of ids, returned in the same order.
 func (c *Client) Batch(ctx context.Context, ids []int64, opts *BatchOptions) (descriptions AssetDescriptions, err error) {
-	items := AssetRequestItemsFromAssetIDs(ids...)
-
-	resp, err := c.client.
-		NewRequest().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"skipSigningScripts": fmt.Sprint(opts.SkipSigningScripts),
-		}).
-		SetBody(AssetRequestItems(items)).
-		AddRetryCondition(func(resp *resty.Response, err error) bool {
-			return strings.Contains(resp.String(), "TooManyRequests") ||
-				strings.Contains(resp.String(), "504 Gateway Time-out")
-		}).
-		Post("https://assetdelivery.roblox.com/v2/assets/batch")
+	return c.BatchItems(ctx, AssetRequestItemsFromAssetIDs(ids...), opts)
+}
+
+// BatchItems looks up the descriptions of items, returned in the same order, with the IDs they were requested by
+// filled in.
+// Items without a request ID are given one, so they can request several versions of the same asset.
+func (c *Client) BatchItems(ctx context.Context, items AssetRequestItems, opts *BatchOptions) (descriptions AssetDescriptions, err error) {
+	filled := make(AssetRequestItems, len(items))
+	for i, item := range items {
+		filled[i] = opts.apply(item)
+	}
+	items = filled
+
+	index, err := indexRequestIDs(items)
 	if err != nil {
-		return nil, fmt.Errorf("err executing request: %w", err)
+		return nil, err
 	}
 
-	const byteOrderMarkAsString = string('\uFEFF')
-	body := bytes.TrimPrefix(resp.Body(), []byte(byteOrderMarkAsString))
+	resp, err := c.execute(ctx, opts.Stats, func() (*resty.Response, error) {
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
 
+		return req.
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(map[string]string{
+				"skipSigningScripts": fmt.Sprint(opts.SkipSigningScripts),
+			}).
+			SetBody(AssetRequestItems(items)).
+			Post(c.baseURL + "/v2/assets/batch")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("err executing request: %w", err)
+	}
+
+	body := trimBOM(resp.Body())
 	err = descriptions.UnmarshalJSON(body)
 	if err != nil {
 		// try unmarshal the errors
 		var errors ErrorsResponse
 		if err2 := errors.UnmarshalJSON(body); err2 != nil {
-			fmt.Println(string(body))
-			// just return the original error
-			return nil, fmt.Errorf("error unmarshaling response body: %w", err)
+			return nil, &MalformedResponseError{StatusCode: resp.StatusCode(), Body: body, Err: err}
 		}
 
 		errors.StatusCode = resp.StatusCode()
 		return nil, fmt.Errorf("error from server: %w", errors)
 	}
 
-	// link the items by request id
-	for i := range descriptions {
-		descriptions[i].AssetID = items[i].AssetID
+	return correlate(items, index, descriptions)
+}
+
+// indexRequestIDs maps the request ID of each item to its position, so that responses can be linked back to it.
+// Request IDs must be unique within a batch, so an ID can't be requested twice.
+func indexRequestIDs(items AssetRequestItems) (map[string]int, error) {
+	index := make(map[string]int, len(items))
+	for i, item := range items {
+		if _, ok := index[item.RequestID]; ok {
+			return nil, fmt.Errorf("request ID %q used twice in one batch", item.RequestID)
+		}
+		index[item.RequestID] = i
+	}
+
+	return index, nil
+}
+
+// correlate returns the descriptions in the order of the items they were requested by, linked by request ID,
+// with their asset IDs, versions or asset version IDs filled in. It returns a *MismatchError if any item is unanswered or answered twice,
+// or if any description answers no item.
+func correlate(items AssetRequestItems, index map[string]int, descriptions AssetDescriptions) (AssetDescriptions, error) {
+	matched := make(AssetDescriptions, len(items))
+	answered := make([]bool, len(items))
+	var mismatch MismatchError
+	for _, description := range descriptions {
+		i, ok := index[description.RequestID]
+		switch {
+		case !ok:
+			mismatch.Unexpected = append(mismatch.Unexpected, description.RequestID)
+			continue
+		case answered[i]:
+			mismatch.Duplicated = append(mismatch.Duplicated, description.RequestID)
+			continue
+		}
+
+		answered[i] = true
+		description.AssetID = items[i].AssetID
+		description.Version = items[i].Version
+		description.AssetVersionID = items[i].AssetVersionID
+		matched[i] = description
+	}
+
+	for i := range items {
+		if !answered[i] {
+			mismatch.Missing = append(mismatch.Missing, items[i].RequestID)
+		}
+	}
+
+	if len(mismatch.Missing) > 0 || len(mismatch.Unexpected) > 0 || len(mismatch.Duplicated) > 0 {
+		return nil, &mismatch
+	}
+
+	return matched, nil
+}
+
+// newRequest returns a request under ctx, authenticated with the next credential if c has any.
+func (c *Client) newRequest(ctx context.Context) (*resty.Request, error) {
+	req := c.client.NewRequest().SetContext(ctx)
+	if c.credentials == nil {
+		return req, nil
+	}
+
+	cred, err := c.credentials.Credential(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get credential: %w", err)
 	}
+	cred.authenticate(req)
 
-	return descriptions, nil
+	return req, nil
 }
 
-func (c *Client) AssetFetchByID(ctx context.Context, id uint64, opts *BatchOptions) (description AssetDescription, err error) {
-	resp, err := c.client.NewRequest().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetQueryParams(map[string]string{
-			"skipSigningScripts": fmt.Sprint(opts.SkipSigningScripts),
-		}).
-		Get(fmt.Sprintf("https://assetdelivery.roblox.com/v2/assetId/%d", id))
+// isRetryable reports whether a request failing with err is worth retrying as is.
+func isRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrGatewayTimeout)
+}
+
+// AssetFetchByID looks up the description of a single asset, with the same retries, byte order mark handling
+// and errors as Batch. Of the item fields in opts, only Version is sent.
+// If the asset itself can't be fetched, the description is returned along with its error, e.g. ErrNotFound.
+func (c *Client) AssetFetchByID(ctx context.Context, id int64, opts *BatchOptions) (description AssetDescription, err error) {
+	params := map[string]string{
+		"skipSigningScripts": fmt.Sprint(opts.SkipSigningScripts),
+	}
+	if opts.Version != 0 {
+		params["version"] = fmt.Sprint(opts.Version)
+	}
+
+	resp, err := c.execute(ctx, opts.Stats, func() (*resty.Response, error) {
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return req.
+			SetHeader("Content-Type", "application/json").
+			SetQueryParams(params).
+			Get(fmt.Sprintf("%s/v2/assetId/%d", c.baseURL, id))
+	})
 	if err != nil {
 		return AssetDescription{}, fmt.Errorf("err executing request: %w", err)
 	}
 
-	if err := description.UnmarshalJSON(resp.Body()); err != nil {
-		return AssetDescription{}, nil
+	body := trimBOM(resp.Body())
+	if err := description.UnmarshalJSON(body); err != nil {
+		return AssetDescription{}, &MalformedResponseError{StatusCode: resp.StatusCode(), Body: body, Err: err}
 	}
+	description.AssetID = id
+	description.Version = opts.Version
 
-	return description, nil
+	failed := ErrorsResponse{Errors: description.Errors, StatusCode: resp.StatusCode()}
+	switch {
+	case isRetryable(failed):
+		// throttled or timed out as a whole, whatever the body says
+		return AssetDescription{}, fmt.Errorf("error from server: %w", failed)
+	case resp.StatusCode() < http.StatusBadRequest || description.Errors.Contains(resp.StatusCode()):
+		// the endpoint reports the asset's own errors with their code as the status, e.g. 404
+		return description, description.Err()
+	default:
+		return AssetDescription{}, fmt.Errorf("error from server: %w", failed)
+	}
+}
+
+// trimBOM strips the byte order mark that the API sometimes puts before a response body.
+func trimBOM(body []byte) []byte {
+	const byteOrderMarkAsString = string('\uFEFF')
+	return bytes.TrimPrefix(body, []byte(byteOrderMarkAsString))
 }
@@ -0,0 +1,154 @@
+package assetdelivery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+)
+
+// replay returns a client for a server that answers every batch request with the recorded response in testdata.
+func replay(t *testing.T, fixture string) *assetdelivery.Client {
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	require.NoError(t, err)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+
+	return assetdelivery.NewClient(resty.New(),
+		assetdelivery.WithBaseURL(s.URL),
+		assetdelivery.WithRetryPolicy(assetdelivery.RetryPolicy{}))
+}
+
+func TestBatchCorrelation(t *testing.T) {
+	ids := []int64{1818, 2, 13}
+	opts := &assetdelivery.BatchOptions{SkipSigningScripts: true}
+
+	t.Run("recorded", func(t *testing.T) {
+		type testCase struct {
+			fixture  string
+			expected *assetdelivery.MismatchError
+		}
+
+		cases := []testCase{
+			{fixture: "batch_ordered.json"},
+			{fixture: "batch_reordered.json"},
+			{fixture: "batch_short.json", expected: &assetdelivery.MismatchError{Missing: []string{"2"}}},
+			{fixture: "batch_extra.json", expected: &assetdelivery.MismatchError{Unexpected: []string{"99"}}},
+			{fixture: "batch_duplicated.json", expected: &assetdelivery.MismatchError{Duplicated: []string{"1818"}}},
+		}
+
+		for _, c := range cases {
+			descriptions, err := replay(t, c.fixture).Batch(context.Background(), ids, opts)
+			if c.expected != nil {
+				assert.ErrorIs(t, err, assetdelivery.ErrResponseMismatch, c.fixture)
+				var mismatch *assetdelivery.MismatchError
+				require.True(t, errors.As(err, &mismatch), c.fixture)
+				assert.Equal(t, c.expected, mismatch, c.fixture)
+				assert.Nil(t, descriptions, c.fixture)
+				continue
+			}
+
+			require.NoError(t, err, c.fixture)
+			require.Len(t, descriptions, len(ids), c.fixture)
+			for i, description := range descriptions {
+				assert.Equal(t, ids[i], description.AssetID, c.fixture)
+			}
+			assert.Equal(t, assetdelivery.Model, descriptions[0].AssetTypeID, c.fixture)
+			assert.ErrorIs(t, descriptions[1].Err(), assetdelivery.ErrNotFound, c.fixture)
+			assert.True(t, descriptions[2].IsArchived, c.fixture)
+		}
+	})
+
+	t.Run("duplicate ids", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		_, err := s.AssetDeliveryClient().Batch(context.Background(), []int64{3, 6, 3}, opts)
+		assert.Error(t, err)
+		assert.Zero(t, s.Requests())
+	})
+}
+
+func TestBatchItems(t *testing.T) {
+	var sent []map[string]interface{}
+	s := fakeassetdelivery.NewServer()
+	defer s.Close()
+	capture := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(buf, &sent))
+
+		// pass the request on, so that the fake checks that it parses
+		resp, err := http.Post(s.URL+r.URL.String(), "application/json", bytes.NewReader(buf))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer capture.Close()
+
+	c := assetdelivery.NewClient(resty.New(),
+		assetdelivery.WithBaseURL(capture.URL),
+		assetdelivery.WithRetryPolicy(assetdelivery.RetryPolicy{}))
+
+	representations := assetdelivery.ContentRepresentations{{Format: "mesh", MajorVersion: "5"}}
+	items := assetdelivery.AssetRequestItems{
+		{AssetID: 3},
+		{AssetID: 3, Version: 2},
+		{AssetVersionID: 1234, Accept: "rbxm"},
+	}
+	descriptions, err := c.BatchItems(context.Background(), items, &assetdelivery.BatchOptions{
+		SkipSigningScripts:     true,
+		AssetType:              assetdelivery.Mesh,
+		Accept:                 "mesh",
+		ContentRepresentations: representations,
+	})
+	require.NoError(t, err)
+	require.Len(t, descriptions, len(items))
+	assert.Equal(t, int64(3), descriptions[0].AssetID)
+	assert.Equal(t, int64(3), descriptions[1].AssetID)
+	assert.Equal(t, 2, descriptions[1].Version)
+	assert.Zero(t, descriptions[2].AssetID)
+	assert.Zero(t, descriptions[2].Version)
+	assert.Equal(t, int64(1234), descriptions[2].AssetVersionID)
+
+	require.Len(t, sent, len(items))
+	assert.Equal(t, "3", sent[0]["requestId"])
+	assert.NotContains(t, sent[0], "version")
+	assert.Equal(t, "3@2", sent[1]["requestId"])
+	assert.EqualValues(t, 2, sent[1]["version"])
+	assert.Equal(t, "v1234", sent[2]["requestId"])
+	assert.EqualValues(t, 1234, sent[2]["assetVersionId"])
+	assert.NotContains(t, sent[2], "assetId")
+	assert.Equal(t, "rbxm", sent[2]["accept"])
+	assert.Equal(t, "mesh", sent[0]["accept"])
+	assert.Equal(t, "Mesh", sent[0]["assetType"])
+
+	encoded, ok := sent[0]["contentRepresentationPriorityList"].(string)
+	require.True(t, ok)
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"format":"mesh","majorVersion":"5"}]`, string(decoded))
+
+	t.Run("does not modify items", func(t *testing.T) {
+		assert.Empty(t, items[0].RequestID)
+		assert.Empty(t, items[0].Accept)
+	})
+}
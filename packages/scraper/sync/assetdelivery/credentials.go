@@ -0,0 +1,180 @@
+package assetdelivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// CookieName is the name of the session cookie of a Roblox account.
+	CookieName = ".ROBLOSECURITY"
+	// APIKeyHeader is the header carrying an Open Cloud API key.
+	APIKeyHeader = "x-api-key"
+)
+
+// ErrNoCredentials is returned by a CredentialsProvider that has no credentials to give.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Credential authenticates requests as one account, by its session cookie, its API key, or both.
+// Its secrets are redacted when it's formatted or marshaled, so that it can't leak through logs.
+type Credential struct {
+	// Name identifies the account in logs, in place of its secrets.
+	Name   string `json:"name"`
+	Cookie string `json:"cookie,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+	// Rate is the most requests per second to make as the account. Zero means unlimited.
+	Rate float64 `json:"rate,omitempty"`
+	// Burst is the most requests to make as the account at once. It defaults to 1.
+	Burst int `json:"burst,omitempty"`
+}
+
+// ParseCredentials parses a JSON list of credentials, as read from the environment.
+func ParseCredentials(s string) ([]Credential, error) {
+	var creds []Credential
+	if err := json.Unmarshal([]byte(s), &creds); err != nil {
+		// the error may quote the input, secrets included
+		return nil, errors.New("credentials aren't a JSON list")
+	}
+
+	return creds, nil
+}
+
+func (c Credential) String() string {
+	var secrets []string
+	if c.Cookie != "" {
+		secrets = append(secrets, "cookie")
+	}
+	if c.APIKey != "" {
+		secrets = append(secrets, "api key")
+	}
+	if len(secrets) == 0 {
+		return c.Name
+	}
+
+	return fmt.Sprintf("%s (%s redacted)", c.Name, strings.Join(secrets, ", "))
+}
+
+func (c Credential) GoString() string {
+	return c.String()
+}
+
+// MarshalJSON marshals c with its secrets redacted.
+func (c Credential) MarshalJSON() ([]byte, error) {
+	type credential Credential
+	redacted := credential(c)
+	if redacted.Cookie != "" {
+		redacted.Cookie = "REDACTED"
+	}
+	if redacted.APIKey != "" {
+		redacted.APIKey = "REDACTED"
+	}
+
+	return json.Marshal(redacted)
+}
+
+// authenticate sets the cookie and API key of c on req.
+func (c Credential) authenticate(req *resty.Request) {
+	if c.Cookie != "" {
+		req.Header.Add("Cookie", CookieName+"="+c.Cookie)
+	}
+	if c.APIKey != "" {
+		req.SetHeader(APIKeyHeader, c.APIKey)
+	}
+}
+
+// CredentialsProvider picks the credential to authenticate each request with.
+type CredentialsProvider interface {
+	// Credential returns the credential for the next request, waiting until its account may make one.
+	Credential(ctx context.Context) (Credential, error)
+}
+
+// WithCredentials authenticates every request of the Client, retries included, with a credential from p.
+func WithCredentials(p CredentialsProvider) Option {
+	return func(c *Client) {
+		c.credentials = p
+	}
+}
+
+// Rotation is a CredentialsProvider that rotates between accounts, each under its own rate limit.
+// It gives out the next account in turn that may make a request right away, or if none may,
+// waits for the one that may make a request the soonest.
+type Rotation struct {
+	mu       sync.Mutex
+	creds    []Credential
+	limiters []*rate.Limiter
+	next     int
+}
+
+// NewRotation returns a Rotation between creds.
+func NewRotation(creds ...Credential) *Rotation {
+	r := &Rotation{
+		creds:    creds,
+		limiters: make([]*rate.Limiter, len(creds)),
+	}
+	for i, cred := range creds {
+		limit, burst := rate.Inf, cred.Burst
+		if cred.Rate > 0 {
+			limit = rate.Limit(cred.Rate)
+		}
+		if burst <= 0 {
+			burst = 1
+		}
+		r.limiters[i] = rate.NewLimiter(limit, burst)
+	}
+
+	return r
+}
+
+func (r *Rotation) Credential(ctx context.Context) (Credential, error) {
+	r.mu.Lock()
+	if len(r.creds) == 0 {
+		r.mu.Unlock()
+		return Credential{}, ErrNoCredentials
+	}
+
+	now := time.Now()
+	soonest, soonestDelay := -1, time.Duration(0)
+	var reservation *rate.Reservation
+	for n := 0; n < len(r.creds); n++ {
+		i := (r.next + n) % len(r.creds)
+		res := r.limiters[i].ReserveN(now, 1)
+		delay := res.DelayFrom(now)
+		if delay == 0 {
+			if reservation != nil {
+				reservation.CancelAt(now)
+			}
+			r.next = i + 1
+			r.mu.Unlock()
+			return r.creds[i], nil
+		}
+
+		if soonest < 0 || delay < soonestDelay {
+			if reservation != nil {
+				reservation.CancelAt(now)
+			}
+			soonest, soonestDelay, reservation = i, delay, res
+		} else {
+			res.CancelAt(now)
+		}
+	}
+	r.next = soonest + 1
+	r.mu.Unlock()
+
+	timer := time.NewTimer(soonestDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		return Credential{}, ctx.Err()
+	case <-timer.C:
+		return r.creds[soonest], nil
+	}
+}
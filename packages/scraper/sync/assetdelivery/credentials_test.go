@@ -0,0 +1,112 @@
+package assetdelivery_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+)
+
+func TestCredentials(t *testing.T) {
+	opts := &assetdelivery.BatchOptions{SkipSigningScripts: true}
+	ids := []int64{3, 6, 9, 12}
+	restricted := func(id int64) bool { return id%6 == 0 }
+
+	t.Run("redacted", func(t *testing.T) {
+		cred := assetdelivery.Credential{Name: "alt", Cookie: "cookie-secret", APIKey: "key-secret"}
+
+		buf, err := json.Marshal(cred)
+		require.NoError(t, err)
+		for _, s := range []string{fmt.Sprint(cred), fmt.Sprintf("%+v", cred), fmt.Sprintf("%#v", cred), string(buf)} {
+			assert.Contains(t, s, "alt")
+			assert.NotContains(t, s, "cookie-secret")
+			assert.NotContains(t, s, "key-secret")
+		}
+
+		_, err = assetdelivery.ParseCredentials(`[{"name": "alt", "cookie": "cookie-secret"`)
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "cookie-secret")
+
+		creds, err := assetdelivery.ParseCredentials(`[{"name": "alt", "cookie": "cookie-secret", "rate": 2}]`)
+		require.NoError(t, err)
+		assert.Equal(t, []assetdelivery.Credential{{Name: "alt", Cookie: "cookie-secret", Rate: 2}}, creds)
+	})
+
+	t.Run("required by the server", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithAuth(restricted, "cookie-secret", "key-secret"))
+		defer s.Close()
+
+		descriptions, err := s.AssetDeliveryClient().Batch(context.Background(), ids, opts)
+		require.NoError(t, err)
+		assert.NoError(t, descriptions[0].Err())
+		assert.ErrorIs(t, descriptions[1].Err(), assetdelivery.ErrUnauthorized)
+
+		_, err = s.AssetDeliveryClient().AssetFetchByID(context.Background(), 6, opts)
+		assert.ErrorIs(t, err, assetdelivery.ErrUnauthorized)
+
+		wrong := assetdelivery.WithCredentials(assetdelivery.NewRotation(assetdelivery.Credential{Name: "wrong", Cookie: "nope"}))
+		descriptions, err = s.AssetDeliveryClient(wrong).Batch(context.Background(), ids, opts)
+		require.NoError(t, err)
+		assert.ErrorIs(t, descriptions[1].Err(), assetdelivery.ErrUnauthorized)
+
+		rotation := assetdelivery.NewRotation(
+			assetdelivery.Credential{Name: "main", Cookie: "cookie-secret"},
+			assetdelivery.Credential{Name: "alt", APIKey: "key-secret"},
+		)
+		cl := s.AssetDeliveryClient(assetdelivery.WithCredentials(rotation))
+		for i := 0; i < 4; i++ {
+			descriptions, err = cl.Batch(context.Background(), ids, opts)
+			require.NoError(t, err)
+			assert.Empty(t, descriptions.Filter(assetdelivery.IsErrored))
+		}
+		assert.Equal(t, 2, s.AuthenticatedRequests("cookie-secret"))
+		assert.Equal(t, 2, s.AuthenticatedRequests("key-secret"))
+
+		description, err := cl.AssetFetchByID(context.Background(), 6, opts)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), description.AssetID)
+	})
+
+	t.Run("per account rate limits", func(t *testing.T) {
+		rotation := assetdelivery.NewRotation(
+			assetdelivery.Credential{Name: "slow", Cookie: "a", Rate: 0.001},
+			assetdelivery.Credential{Name: "fast", Cookie: "b"},
+		)
+
+		var names []string
+		for i := 0; i < 4; i++ {
+			cred, err := rotation.Credential(context.Background())
+			require.NoError(t, err)
+			names = append(names, cred.Name)
+		}
+		// the slow account is skipped while it waits out its limit
+		assert.Equal(t, []string{"slow", "fast", "fast", "fast"}, names)
+
+		exhausted := assetdelivery.NewRotation(assetdelivery.Credential{Name: "slow", Cookie: "a", Rate: 0.001})
+		_, err := exhausted.Credential(context.Background())
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = exhausted.Credential(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		cl := s.AssetDeliveryClient(
+			assetdelivery.WithCredentials(assetdelivery.NewRotation()),
+			assetdelivery.WithRetryPolicy(assetdelivery.DefaultRetryPolicy),
+		)
+		_, err := cl.Batch(context.Background(), ids, opts)
+		assert.ErrorIs(t, err, assetdelivery.ErrNoCredentials)
+		assert.Zero(t, s.Requests())
+	})
+}
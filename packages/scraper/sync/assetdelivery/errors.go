@@ -0,0 +1,164 @@
+package assetdelivery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors returned by the Asset Delivery API, for use with errors.Is. They survive wrapping.
+var (
+	// ErrRateLimited means the API throttled the request (429).
+	ErrRateLimited = errors.New("rate limited")
+	// ErrForbidden means the API refused the request outright (403), typically because the caller is blocked.
+	ErrForbidden = errors.New("forbidden")
+	// ErrGatewayTimeout means the load balancer in front of the API timed out (504).
+	ErrGatewayTimeout = errors.New("gateway timeout")
+	// ErrMalformedResponse means the response body was neither asset descriptions nor API errors.
+	ErrMalformedResponse = errors.New("malformed response body")
+	// ErrResponseMismatch means the descriptions in a batch response didn't answer the requested items one to one.
+	ErrResponseMismatch = errors.New("batch response doesn't match request")
+)
+
+// Errors describing why a single asset in a batch couldn't be delivered, for use with errors.Is
+// on AssetDescription.Err or on an Error.
+var (
+	ErrNotFound     = errors.New("asset not found")
+	ErrUnauthorized = errors.New("not authorized to access asset")
+	ErrModerated    = errors.New("asset moderated")
+)
+
+// Codes of per-asset errors, as seen in AssetDescription.Errors.
+const (
+	CodeUnauthorized = 403
+	CodeNotFound     = 404
+	CodeModerated    = 409
+)
+
+// statusError returns the sentinel error for a response status code, or nil if there is none.
+func statusError(statusCode int) error {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusGatewayTimeout:
+		return ErrGatewayTimeout
+	default:
+		return nil
+	}
+}
+
+// Is reports whether the response status matches target, e.g. ErrRateLimited for a 429.
+func (e ErrorsResponse) Is(target error) bool {
+	if err := statusError(e.StatusCode); err != nil && err == target {
+		return true
+	}
+
+	// the API also reports throttling in the body, whatever the status
+	return target == ErrRateLimited && e.Errors.containsMessage("TooManyRequests")
+}
+
+func (e Errors) containsMessage(msg string) bool {
+	for _, err := range e {
+		if err.Message == msg {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Is classifies e by its code, e.g. as ErrNotFound for a 404.
+func (e Error) Is(target error) bool {
+	switch e.Code {
+	case CodeUnauthorized:
+		return target == ErrUnauthorized
+	case CodeNotFound:
+		return target == ErrNotFound
+	case CodeModerated:
+		return target == ErrModerated
+	default:
+		return false
+	}
+}
+
+func (e Errors) Error() string {
+	switch len(e) {
+	case 0:
+		return "no errors"
+	case 1:
+		return e[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e[0].Error(), len(e)-1)
+	}
+}
+
+// Is reports whether any of the errors in e matches target.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Err returns the errors of the description, or nil if it has none.
+func (a AssetDescription) Err() error {
+	if len(a.Errors) == 0 {
+		return nil
+	}
+
+	return a.Errors
+}
+
+// MalformedResponseError is returned for a response body that couldn't be parsed, such as an HTML error page.
+// It matches ErrMalformedResponse, as well as the sentinel error for its status code.
+type MalformedResponseError struct {
+	StatusCode int
+	Body       []byte
+	Err        error
+}
+
+func (e *MalformedResponseError) Error() string {
+	const maxBody = 256
+	body := e.Body
+	if len(body) > maxBody {
+		body = body[:maxBody]
+	}
+
+	return fmt.Sprintf("%s (status %d): %s: %q", ErrMalformedResponse, e.StatusCode, e.Err, body)
+}
+
+func (e *MalformedResponseError) Unwrap() error {
+	return e.Err
+}
+
+func (e *MalformedResponseError) Is(target error) bool {
+	return target == ErrMalformedResponse || target == statusError(e.StatusCode)
+}
+
+// MismatchError is returned for a batch response whose descriptions don't answer the requested items one to one,
+// identified by their request IDs. It matches ErrResponseMismatch.
+type MismatchError struct {
+	// Missing holds the request IDs of the items without a description.
+	Missing []string
+	// Unexpected holds the request IDs of the descriptions that answer no requested item.
+	Unexpected []string
+	// Duplicated holds the request IDs of the items answered more than once.
+	Duplicated []string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("%s: missing %v, unexpected %v, duplicated %v", ErrResponseMismatch, e.Missing, e.Unexpected, e.Duplicated)
+}
+
+func (e *MismatchError) Is(target error) bool {
+	return target == ErrResponseMismatch
+}
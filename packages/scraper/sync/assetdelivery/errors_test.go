@@ -0,0 +1,128 @@
+package assetdelivery_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+)
+
+func TestErrors(t *testing.T) {
+	opts := &assetdelivery.BatchOptions{SkipSigningScripts: true}
+
+	t.Run("responses", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		type testCase struct {
+			status    int
+			expected  error
+			malformed bool
+		}
+
+		cases := []testCase{
+			{status: http.StatusTooManyRequests, expected: assetdelivery.ErrRateLimited},
+			{status: http.StatusForbidden, expected: assetdelivery.ErrForbidden},
+			{status: http.StatusGatewayTimeout, expected: assetdelivery.ErrGatewayTimeout, malformed: true},
+		}
+
+		sentinels := []error{assetdelivery.ErrRateLimited, assetdelivery.ErrForbidden, assetdelivery.ErrGatewayTimeout}
+		for _, c := range cases {
+			s.Fail(c.status, 1)
+			_, err := s.AssetDeliveryClient().Batch(context.Background(), []int64{1, 2, 3}, opts)
+			require.Error(t, err)
+
+			// classification survives further wrapping by callers
+			err = fmt.Errorf("index range: %w", err)
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == c.expected, errors.Is(err, sentinel), "%d: %v", c.status, sentinel)
+			}
+			assert.Equal(t, c.malformed, errors.Is(err, assetdelivery.ErrMalformedResponse))
+		}
+	})
+
+	t.Run("throttling reported in the body", func(t *testing.T) {
+		err := assetdelivery.ErrorsResponse{
+			Errors:     assetdelivery.Errors{{Message: "TooManyRequests"}},
+			StatusCode: http.StatusOK,
+		}
+		assert.ErrorIs(t, err, assetdelivery.ErrRateLimited)
+	})
+
+	t.Run("per asset", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithDescriber(func(id int64) assetdelivery.AssetDescription {
+			if id == 1 {
+				return fakeassetdelivery.Synthetic(3)
+			}
+			return assetdelivery.AssetDescription{Errors: assetdelivery.Errors{{Code: int(id), Message: http.StatusText(int(id))}}}
+		}))
+		defer s.Close()
+
+		descriptions, err := s.AssetDeliveryClient().Batch(context.Background(), []int64{1, 403, 404, 409, 500}, opts)
+		require.NoError(t, err)
+
+		assert.NoError(t, descriptions[0].Err())
+		assert.ErrorIs(t, descriptions[1].Err(), assetdelivery.ErrUnauthorized)
+		assert.ErrorIs(t, descriptions[2].Err(), assetdelivery.ErrNotFound)
+		assert.ErrorIs(t, descriptions[3].Err(), assetdelivery.ErrModerated)
+		assert.Error(t, descriptions[4].Err())
+		assert.NotErrorIs(t, descriptions[4].Err(), assetdelivery.ErrNotFound)
+		assert.Equal(t, "Not Found (code 404)", descriptions[2].Err().Error())
+	})
+	t.Run("single asset", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithBOM())
+		defer s.Close()
+		cl := s.AssetDeliveryClient()
+
+		description, err := cl.AssetFetchByID(context.Background(), 6, opts)
+		require.NoError(t, err)
+		assert.Equal(t, int64(6), description.AssetID)
+		assert.Equal(t, assetdelivery.Audio, description.AssetTypeID)
+
+		description, err = cl.AssetFetchByID(context.Background(), 6, &assetdelivery.BatchOptions{Version: 2})
+		require.NoError(t, err)
+		assert.Equal(t, 2, description.Version)
+		assert.Equal(t, "2", description.Etag())
+
+		description, err = cl.AssetFetchByID(context.Background(), 7, opts)
+		assert.ErrorIs(t, err, assetdelivery.ErrNotFound)
+		assert.Equal(t, int64(7), description.AssetID)
+
+		_, err = cl.AssetFetchByID(context.Background(), 6, &assetdelivery.BatchOptions{Version: 9})
+		assert.ErrorIs(t, err, assetdelivery.ErrNotFound)
+
+		type testCase struct {
+			status    int
+			expected  error
+			malformed bool
+		}
+
+		cases := []testCase{
+			{status: http.StatusTooManyRequests, expected: assetdelivery.ErrRateLimited},
+			{status: http.StatusForbidden, expected: assetdelivery.ErrForbidden},
+			{status: http.StatusGatewayTimeout, expected: assetdelivery.ErrGatewayTimeout, malformed: true},
+		}
+
+		for _, c := range cases {
+			s.Fail(c.status, 1)
+			_, err := cl.AssetFetchByID(context.Background(), 6, opts)
+			assert.ErrorIs(t, err, c.expected, "%d", c.status)
+			assert.Equal(t, c.malformed, errors.Is(err, assetdelivery.ErrMalformedResponse), "%d", c.status)
+		}
+
+		// retried like Batch
+		s.Fail(http.StatusTooManyRequests, 2)
+		var stats assetdelivery.CallStats
+		_, err = s.AssetDeliveryClient(assetdelivery.WithRetryPolicy(assetdelivery.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})).
+			AssetFetchByID(context.Background(), 6, &assetdelivery.BatchOptions{Stats: &stats})
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats.Retries)
+	})
+}
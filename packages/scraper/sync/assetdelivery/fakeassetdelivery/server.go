@@ -0,0 +1,350 @@
+// Package fakeassetdelivery provides an in-process fake of the Asset Delivery API and asset CDN,
+// so that the indexer can be tested without the real service or a proxy.
+package fakeassetdelivery
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+)
+
+const byteOrderMark = "\uFEFF"
+
+// gatewayTimeoutBody is the page served by the load balancer in front of the real API when it times out.
+const gatewayTimeoutBody = `<html>
+<head><title>504 Gateway Time-out</title></head>
+<body>
+<center><h1>504 Gateway Time-out</h1></center>
+</body>
+</html>
+`
+
+// Server is a fake Asset Delivery API. It serves batch and single asset lookups from a Describer,
+// and the asset contents from a fake CDN under /cdn/.
+type Server struct {
+	*httptest.Server
+
+	describe   Describer
+	versions   VersionCounter
+	deleted    func(id int64, version int) bool
+	bom        bool
+	retryAfter time.Duration
+	restricted func(id int64) bool
+	secrets    map[string]bool
+
+	mu       sync.Mutex
+	faults   []int
+	requests int
+	authed   map[string]int
+}
+
+// Describer returns the description served for an asset ID. Locations starting with / are served relative to the Server.
+type Describer func(id int64) assetdelivery.AssetDescription
+
+// VersionCounter returns the number of versions of an asset ID.
+type VersionCounter func(id int64) int
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithDescriber serves descriptions from d instead of Synthetic.
+func WithDescriber(d Describer) Option {
+	return func(s *Server) {
+		s.describe = d
+	}
+}
+
+// WithVersions counts the versions of assets with v instead of SyntheticVersions.
+func WithVersions(v VersionCounter) Option {
+	return func(s *Server) {
+		s.versions = v
+	}
+}
+
+// WithDeletedVersions makes the versions for which deleted returns true not found, as if they were deleted,
+// while the versions after them are still served.
+func WithDeletedVersions(deleted func(id int64, version int) bool) Option {
+	return func(s *Server) {
+		s.deleted = deleted
+	}
+}
+
+// WithAuth makes the assets for which restricted returns true require authentication: unless a request carries
+// one of secrets as its session cookie or API key, they are refused with the per-item error of the real API.
+func WithAuth(restricted func(id int64) bool, secrets ...string) Option {
+	return func(s *Server) {
+		s.restricted = restricted
+		s.secrets = make(map[string]bool, len(secrets))
+		for _, secret := range secrets {
+			s.secrets[secret] = true
+		}
+	}
+}
+
+// WithBOM prefixes every API response body with a byte order mark, as the real API sometimes does.
+func WithBOM() Option {
+	return func(s *Server) {
+		s.bom = true
+	}
+}
+
+// WithRetryAfter sets a Retry-After header of d, rounded up to whole seconds, on throttled responses.
+func WithRetryAfter(d time.Duration) Option {
+	return func(s *Server) {
+		s.retryAfter = d
+	}
+}
+
+// NewServer starts a Server. The caller should call Close when finished, to shut it down.
+func NewServer(opts ...Option) *Server {
+	s := &Server{describe: Synthetic, versions: SyntheticVersions}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/assets/batch", s.handleBatch)
+	mux.HandleFunc("/v2/assetId/", s.handleAsset)
+	mux.HandleFunc("/cdn/", s.handleCDN)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// AssetDeliveryClient returns an assetdelivery.Client that sends its requests to s.
+// Unless opts say otherwise, it doesn't retry failed requests, so that every fault reaches the caller.
+func (s *Server) AssetDeliveryClient(opts ...assetdelivery.Option) *assetdelivery.Client {
+	opts = append([]assetdelivery.Option{
+		assetdelivery.WithBaseURL(s.URL),
+		assetdelivery.WithRetryPolicy(assetdelivery.RetryPolicy{}),
+	}, opts...)
+
+	return assetdelivery.NewClient(resty.New(), opts...)
+}
+
+// Fail makes the next n API requests fail with the given status code, after any failures already queued.
+// 403 and 429 are answered with the JSON errors of the real API, and 504 with the HTML page of its load balancer.
+func (s *Server) Fail(status, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.faults = append(s.faults, status)
+	}
+}
+
+// Requests returns the number of API requests received so far, including failed ones.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// AuthenticatedRequests returns the number of API requests authorized by secret, as their session cookie or API key.
+func (s *Server) AuthenticatedRequests(secret string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.authed[secret]
+}
+
+// authorize reports whether r carries an accepted secret, and counts it against the secret.
+func (s *Server) authorize(r *http.Request) bool {
+	secret := r.Header.Get(assetdelivery.APIKeyHeader)
+	if cookie, err := r.Cookie(assetdelivery.CookieName); err == nil {
+		secret = cookie.Value
+	}
+	if !s.secrets[secret] {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.authed == nil {
+		s.authed = make(map[string]int)
+	}
+	s.authed[secret]++
+
+	return true
+}
+
+// nextFault counts a request and returns the status code it should fail with, or 0 if it shouldn't.
+func (s *Server) nextFault() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	if len(s.faults) == 0 {
+		return 0
+	}
+
+	status := s.faults[0]
+	s.faults = s.faults[1:]
+	return status
+}
+
+func (s *Server) writeFault(w http.ResponseWriter, status int) {
+	switch status {
+	case http.StatusGatewayTimeout:
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(status)
+		io.WriteString(w, gatewayTimeoutBody)
+	case http.StatusTooManyRequests:
+		if s.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((s.retryAfter+time.Second-1)/time.Second)))
+		}
+		s.writeErrors(w, status, assetdelivery.Error{Code: 0, Message: "TooManyRequests"})
+	default:
+		s.writeErrors(w, status, assetdelivery.Error{Code: 0, Message: strings.ReplaceAll(http.StatusText(status), " ", "")})
+	}
+}
+
+func (s *Server) writeErrors(w http.ResponseWriter, status int, errs ...assetdelivery.Error) {
+	body, _ := assetdelivery.ErrorsResponse{Errors: errs}.MarshalJSON()
+	s.writeJSON(w, status, body)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if s.bom {
+		io.WriteString(w, byteOrderMark)
+	}
+	w.Write(body)
+}
+
+// describeAt returns the description of a version of id, or of its latest version if version is 0,
+// with relative locations resolved against s. The relative locations of a version get the version appended,
+// e.g. /cdn/<id>/<version>. Assets requiring authentication are refused unless authorized.
+func (s *Server) describeAt(id int64, version int, authorized bool) assetdelivery.AssetDescription {
+	description := s.describe(id)
+	description.AssetID = 0 // the real API doesn't echo the asset ID
+	if len(description.Errors) == 0 && s.restricted != nil && s.restricted(id) && !authorized {
+		return assetdelivery.AssetDescription{
+			Errors: assetdelivery.Errors{{Code: assetdelivery.CodeUnauthorized, Message: "User is not authorized to access Asset."}},
+		}
+	}
+	if version != 0 && len(description.Errors) == 0 && (version < 0 || version > s.versions(id) || s.deleted != nil && s.deleted(id, version)) {
+		return assetdelivery.AssetDescription{
+			Errors: assetdelivery.Errors{{Code: 404, Message: "Requested version does not exist"}},
+		}
+	}
+
+	if len(description.Locations) > 0 {
+		locations := make(assetdelivery.Locations, len(description.Locations))
+		for i, loc := range description.Locations {
+			if strings.HasPrefix(loc.Location, "/") {
+				if version != 0 {
+					loc.Location += "/" + strconv.Itoa(version)
+				}
+				loc.Location = s.URL + loc.Location
+			}
+			locations[i] = loc
+		}
+		description.Locations = locations
+	}
+
+	return description
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.writeErrors(w, http.StatusMethodNotAllowed, assetdelivery.Error{Message: "The requested resource does not support http method '" + r.Method + "'."})
+		return
+	}
+	if status := s.nextFault(); status != 0 {
+		s.writeFault(w, status)
+		return
+	}
+
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErrors(w, http.StatusBadRequest, assetdelivery.Error{Message: err.Error()})
+		return
+	}
+
+	authorized := s.authorize(r)
+	var items assetdelivery.AssetRequestItems
+	if err := items.UnmarshalJSON(buf); err != nil {
+		s.writeErrors(w, http.StatusBadRequest, assetdelivery.Error{Message: "Invalid request body"})
+		return
+	}
+	if len(items) > assetdelivery.MaxBatchSize {
+		s.writeErrors(w, http.StatusBadRequest, assetdelivery.Error{Message: fmt.Sprintf("Batch size cannot exceed %d", assetdelivery.MaxBatchSize)})
+		return
+	}
+
+	descriptions := make(assetdelivery.AssetDescriptions, len(items))
+	for i, item := range items {
+		descriptions[i] = s.describeAt(item.AssetID, item.Version, authorized)
+		descriptions[i].RequestID = item.RequestID
+	}
+
+	body, _ := descriptions.MarshalJSON()
+	s.writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) handleAsset(w http.ResponseWriter, r *http.Request) {
+	if status := s.nextFault(); status != 0 {
+		s.writeFault(w, status)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/v2/assetId/"), 10, 64)
+	if err != nil {
+		s.writeErrors(w, http.StatusBadRequest, assetdelivery.Error{Message: "Invalid asset ID"})
+		return
+	}
+
+	var version int
+	if v := r.URL.Query().Get("version"); v != "" {
+		if version, err = strconv.Atoi(v); err != nil {
+			s.writeErrors(w, http.StatusBadRequest, assetdelivery.Error{Message: "Invalid version"})
+			return
+		}
+	}
+
+	description := s.describeAt(id, version, s.authorize(r))
+	status := http.StatusOK
+	if len(description.Errors) > 0 {
+		status = description.Errors[0].Code
+	}
+
+	body, _ := description.MarshalJSON()
+	s.writeJSON(w, status, body)
+}
+
+func (s *Server) handleCDN(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/cdn/")
+	var version int
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		var err error
+		if version, err = strconv.Atoi(path[i+1:]); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		path = path[:i]
+	}
+
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if version != 0 {
+		w.Write(VersionContent(id, version))
+		return
+	}
+	w.Write(Content(id))
+}
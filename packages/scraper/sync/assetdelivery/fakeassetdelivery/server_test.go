@@ -0,0 +1,104 @@
+package fakeassetdelivery
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+)
+
+func TestServer(t *testing.T) {
+	ids := []int64{1, 3, 6, 9, 15}
+	opts := &assetdelivery.BatchOptions{SkipSigningScripts: true}
+
+	t.Run("batch", func(t *testing.T) {
+		s := NewServer()
+		defer s.Close()
+
+		descriptions, err := s.AssetDeliveryClient().Batch(context.Background(), ids, opts)
+		require.NoError(t, err)
+		require.Len(t, descriptions, len(ids))
+
+		assert.True(t, descriptions[0].Errors.Contains(404))
+		assert.Equal(t, assetdelivery.Decal, descriptions[1].AssetTypeID)
+		assert.Equal(t, assetdelivery.Audio, descriptions[2].AssetTypeID)
+		assert.True(t, descriptions[3].IsArchived)
+		assert.True(t, descriptions[4].IsCopyrightProtected)
+		for i, id := range ids {
+			assert.Equal(t, id, descriptions[i].AssetID)
+		}
+
+		resp, err := http.Get(descriptions[1].Locations[0].Location)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		content, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, Content(3), content)
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		s := NewServer()
+		defer s.Close()
+
+		items := assetdelivery.AssetRequestItems{{AssetID: 6, Version: 3}, {AssetID: 6, Version: 4}}
+		descriptions, err := s.AssetDeliveryClient().BatchItems(context.Background(), items, opts)
+		require.NoError(t, err)
+		require.Len(t, descriptions, len(items))
+		assert.ErrorIs(t, descriptions[1].Err(), assetdelivery.ErrNotFound)
+
+		resp, err := http.Get(descriptions[0].Locations[0].Location)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		content, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, VersionContent(6, 3), content)
+	})
+
+	t.Run("byte order mark", func(t *testing.T) {
+		s := NewServer(WithBOM())
+		defer s.Close()
+
+		descriptions, err := s.AssetDeliveryClient().Batch(context.Background(), ids, opts)
+		require.NoError(t, err)
+		assert.Len(t, descriptions, len(ids))
+	})
+
+	t.Run("faults", func(t *testing.T) {
+		s := NewServer()
+		defer s.Close()
+		s.Fail(http.StatusForbidden, 1)
+		s.Fail(http.StatusTooManyRequests, 1)
+		s.Fail(http.StatusGatewayTimeout, 1)
+
+		cl := s.AssetDeliveryClient()
+		for _, status := range []int{http.StatusForbidden, http.StatusTooManyRequests} {
+			_, err := cl.Batch(context.Background(), ids, opts)
+			var errs assetdelivery.ErrorsResponse
+			require.True(t, errors.As(err, &errs), err)
+			assert.Equal(t, status, errs.StatusCode)
+		}
+
+		_, err := cl.Batch(context.Background(), ids, opts)
+		assert.ErrorIs(t, err, assetdelivery.ErrGatewayTimeout)
+
+		_, err = cl.Batch(context.Background(), ids, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, s.Requests())
+	})
+
+	t.Run("custom describer", func(t *testing.T) {
+		s := NewServer(WithDescriber(func(id int64) assetdelivery.AssetDescription {
+			return assetdelivery.AssetDescription{AssetTypeID: assetdelivery.AssetType(id)}
+		}))
+		defer s.Close()
+
+		description, err := s.AssetDeliveryClient().AssetFetchByID(context.Background(), 42, opts)
+		require.NoError(t, err)
+		assert.Equal(t, assetdelivery.AssetType(42), description.AssetTypeID)
+	})
+}
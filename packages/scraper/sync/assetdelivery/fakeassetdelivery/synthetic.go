@@ -0,0 +1,59 @@
+package fakeassetdelivery
+
+import (
+	"fmt"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+)
+
+// syntheticTypes are the asset types of the synthetic ID space, in rotation.
+var syntheticTypes = []assetdelivery.AssetType{
+	assetdelivery.Model,
+	assetdelivery.Decal,
+	assetdelivery.Audio,
+	assetdelivery.Mesh,
+	assetdelivery.Image,
+}
+
+// Synthetic describes a deterministic synthetic ID space:
+//
+//   - only positive multiples of 3 hold an asset; every other ID is not found
+//   - asset 3k has type syntheticTypes[k % 5], i.e. Model, Decal, Audio, Mesh, Image in turn
+//   - multiples of 9 are archived, multiples of 15 are copyright protected, and multiples of 21 are hash-dynamic
+//   - every asset is served in the source format from /cdn/<id>, with contents given by Content
+//   - asset 3k has 1 + k % 3 versions, as given by SyntheticVersions
+func Synthetic(id int64) assetdelivery.AssetDescription {
+	if id <= 0 || id%3 != 0 {
+		return assetdelivery.AssetDescription{
+			AssetID: id,
+			Errors:  assetdelivery.Errors{{Code: 404, Message: "Request asset was not found"}},
+		}
+	}
+
+	return assetdelivery.AssetDescription{
+		AssetID:              id,
+		AssetTypeID:          syntheticTypes[(id/3)%int64(len(syntheticTypes))],
+		IsArchived:           id%9 == 0,
+		IsCopyrightProtected: id%15 == 0,
+		IsHashDynamic:        id%21 == 0,
+		Locations: assetdelivery.Locations{{
+			AssetFormat: "source",
+			Location:    fmt.Sprintf("/cdn/%d", id),
+		}},
+	}
+}
+
+// Content returns the contents served by the fake CDN for an asset ID.
+func Content(id int64) []byte {
+	return []byte(fmt.Sprintf("synthetic asset %d\n", id))
+}
+
+// SyntheticVersions returns the number of versions of an asset in the synthetic ID space.
+func SyntheticVersions(id int64) int {
+	return 1 + int((id/3)%3)
+}
+
+// VersionContent returns the contents served by the fake CDN for a version of an asset.
+func VersionContent(id int64, version int) []byte {
+	return []byte(fmt.Sprintf("synthetic asset %d version %d\n", id, version))
+}
@@ -1,8 +1,11 @@
 package assetdelivery
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"strings"
 )
 
 //easyjson:json
@@ -43,7 +46,32 @@ func (e ErrorsResponse) Error() string {
 //easyjson:json
 type AssetRequestItem struct {
 	RequestID string `json:"requestId"`
-	AssetID   int64  `json:"assetId"`
+	AssetID   int64  `json:"assetId,omitempty"`
+	// Version is the version of the asset to fetch, counting from 1. The latest version is fetched if it's 0.
+	Version int `json:"version,omitempty"`
+	// AssetVersionID identifies a version of an asset on its own, in place of AssetID and Version.
+	AssetVersionID int64 `json:"assetVersionId,omitempty"`
+	// AssetType is the type the asset is expected to have, which decides the formats it can be fetched in.
+	AssetType AssetTypeName `json:"assetType,omitempty"`
+	// Accept is the format to fetch the asset in, e.g. "rbxm" or "png".
+	Accept string `json:"accept,omitempty"`
+	// ContentRepresentations are the representations to fetch the asset in, in order of preference.
+	ContentRepresentations ContentRepresentations `json:"contentRepresentationPriorityList,omitempty"`
+	// DoNotFallbackToBaselineRepresentation fails the item rather than fetching the baseline representation
+	// if none of ContentRepresentations is available.
+	DoNotFallbackToBaselineRepresentation bool `json:"doNotFallbackToBaselineRepresentation,omitempty"`
+}
+
+// defaultRequestID returns a request ID telling i apart from the items for other assets and versions.
+func (i AssetRequestItem) defaultRequestID() string {
+	switch {
+	case i.AssetVersionID != 0:
+		return fmt.Sprintf("v%d", i.AssetVersionID)
+	case i.Version != 0:
+		return fmt.Sprintf("%d@%d", i.AssetID, i.Version)
+	default:
+		return fmt.Sprint(i.AssetID)
+	}
 }
 
 //easyjson:json
@@ -61,6 +89,46 @@ func AssetRequestItemsFromAssetIDs(ids ...int64) AssetRequestItems {
 	return items
 }
 
+//easyjson:json
+type ContentRepresentation struct {
+	// Format is the name of the representation, e.g. "mesh".
+	Format string `json:"format"`
+	// MajorVersion is the version of the format, e.g. "5" for meshes.
+	MajorVersion string `json:"majorVersion"`
+	// Fidelity is the level of detail of the representation, if the format has several.
+	Fidelity string `json:"fidelity,omitempty"`
+}
+
+//easyjson:json
+type contentRepresentationList []ContentRepresentation
+
+// ContentRepresentations is a list of content representations in order of preference.
+// The API takes it as base64-encoded JSON rather than as a JSON array.
+type ContentRepresentations []ContentRepresentation
+
+func (c ContentRepresentations) MarshalJSON() ([]byte, error) {
+	buf, err := contentRepresentationList(c).MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(base64.StdEncoding.EncodeToString(buf))
+}
+
+func (c *ContentRepresentations) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("content representation priority list isn't base64: %w", err)
+	}
+
+	return (*contentRepresentationList)(c).UnmarshalJSON(buf)
+}
+
 //easyjson:json
 type Location struct {
 	AssetFormat string `json:"assetFormat" csv:"asset_format"`
@@ -70,54 +138,78 @@ type Location struct {
 //easyjson:json
 type Locations []Location
 
+// Etag returns the last element of the location's URL, which identifies its contents.
+func (l Location) Etag() string {
+	return filepath.Base(l.Location)
+}
+
+// Preferred returns the first location in the first of formats that any location is in,
+// compared case-insensitively, or the first location if none is in any of formats.
+// It returns false if there are no locations.
+func (l Locations) Preferred(formats ...string) (Location, bool) {
+	if len(l) == 0 {
+		return Location{}, false
+	}
+
+	for _, format := range formats {
+		for _, loc := range l {
+			if strings.EqualFold(loc.AssetFormat, format) {
+				return loc, true
+			}
+		}
+	}
+
+	return l[0], true
+}
+
 //easyjson:json
 type AssetDescription struct {
 	Locations            Locations `json:"locations" csv:"locations" csv[]:"1"`
 	Errors               Errors    `json:"errors,omitempty" csv:"-"`
 	RequestID            string    `json:"requestId" csv:"-"`
-	IsHashDynamic        bool      `csv:"is_hash_dynamic"`
-	IsCopyrightProtected bool      `csv:"is_copyright_protected"`
+	IsHashDynamic        bool      `json:"isHashDynamic" csv:"is_hash_dynamic"`
+	IsCopyrightProtected bool      `json:"isCopyrightProtected" csv:"is_copyright_protected"`
 	IsArchived           bool      `json:"isArchived" csv:"is_archived"`
-	AssetTypeID          int       `json:"assetTypeId" csv:"asset_type_id"`
+	AssetTypeID          AssetType `json:"assetTypeId" csv:"asset_type_id"`
 
 	AssetID int64 `json:"assetId,omitempty" csv:"asset_id"`
+	// Version is the version of the asset that was requested, or 0 for the latest. Like AssetID,
+	// it isn't sent by the API, but filled in from the request.
+	Version int `json:"version,omitempty" csv:"version"`
+	// AssetVersionID is the asset version ID that was requested, if any, in which case AssetID and Version
+	// are unknown and left 0.
+	AssetVersionID int64 `json:"assetVersionId,omitempty" csv:"asset_version_id"`
 }
 
+// Etag returns the etag of the asset's first location, or "" if it has none.
 func (a AssetDescription) Etag() string {
-	return filepath.Base(a.Locations[0].Location)
+	if len(a.Locations) == 0 {
+		return ""
+	}
+
+	return a.Locations[0].Etag()
 }
 
 //easyjson:json
 type AssetDescriptions []AssetDescription
 
 func (a AssetDescriptions) DiscardErrored() (filtered AssetDescriptions) {
-	for i := range a {
-		if a[i].Errors != nil {
-			continue
-		}
-
-		filtered = append(filtered, a[i])
-	}
-
-	return
+	return a.Filter(Not(IsErrored))
 }
 
-func (a AssetDescriptions) FilterByAssetType(assetType int) (filtered AssetDescriptions) {
-	for i := range a {
-		if a[i].AssetTypeID != assetType {
-			continue
-		}
-
-		filtered = append(filtered, a[i])
-	}
+func (a AssetDescriptions) FilterByAssetType(assetType AssetType) (filtered AssetDescriptions) {
+	return a.FilterByAssetTypes(assetType)
+}
 
-	return
+// FilterByAssetTypes keeps the descriptions whose asset type is any of assetTypes.
+func (a AssetDescriptions) FilterByAssetTypes(assetTypes ...AssetType) (filtered AssetDescriptions) {
+	return a.Filter(HasAssetType(assetTypes...))
 }
 
 func (a AssetDescriptions) DedupByEtag() (deduped AssetDescriptions) {
 	alreadyFound := make(map[string]struct{})
 	for i := range a {
-		etag := filepath.Base(a[i].Etag())
+		etag := a[i].Etag()
 		if _, ok := alreadyFound[etag]; ok {
 			continue
 		}
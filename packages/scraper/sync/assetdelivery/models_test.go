@@ -0,0 +1,46 @@
+package assetdelivery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocations(t *testing.T) {
+	locations := Locations{
+		{AssetFormat: "source", Location: "https://c0.rbxcdn.com/aaa"},
+		{AssetFormat: "mesh", Location: "https://c1.rbxcdn.com/bbb"},
+		{AssetFormat: "rbxm", Location: "https://c2.rbxcdn.com/ccc"},
+	}
+
+	t.Run("preferred", func(t *testing.T) {
+		type testCase struct {
+			formats  []string
+			expected string
+		}
+
+		cases := []testCase{
+			{formats: nil, expected: "source"},
+			{formats: []string{"rbxm"}, expected: "rbxm"},
+			{formats: []string{"MESH", "rbxm"}, expected: "mesh"},
+			{formats: []string{"png", "rbxm", "mesh"}, expected: "rbxm"},
+			// falls back to the first location
+			{formats: []string{"png"}, expected: "source"},
+		}
+
+		for _, c := range cases {
+			loc, ok := locations.Preferred(c.formats...)
+			assert.True(t, ok, "%v", c.formats)
+			assert.Equal(t, c.expected, loc.AssetFormat, "%v", c.formats)
+		}
+
+		_, ok := Locations(nil).Preferred("source")
+		assert.False(t, ok)
+	})
+
+	t.Run("etag", func(t *testing.T) {
+		assert.Equal(t, "bbb", locations[1].Etag())
+		assert.Equal(t, "aaa", AssetDescription{Locations: locations}.Etag())
+		assert.Equal(t, "", AssetDescription{}.Etag())
+	})
+}
@@ -0,0 +1,466 @@
+package assetdelivery
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+)
+
+// Predicate decides whether an asset is selected, e.g. for download.
+// Its String method returns an expression that ParsePredicate parses back into an equivalent Predicate.
+type Predicate interface {
+	Match(a AssetDescription) bool
+	String() string
+}
+
+// Filter returns the descriptions matched by p.
+func (a AssetDescriptions) Filter(p Predicate) (filtered AssetDescriptions) {
+	for i := range a {
+		if p.Match(a[i]) {
+			filtered = append(filtered, a[i])
+		}
+	}
+
+	return
+}
+
+type andPredicate []Predicate
+
+// And matches the assets matched by every one of ps. With no arguments, it matches everything.
+func And(ps ...Predicate) Predicate {
+	if len(ps) == 1 {
+		return ps[0]
+	}
+
+	return andPredicate(ps)
+}
+
+func (p andPredicate) Match(a AssetDescription) bool {
+	for _, q := range p {
+		if !q.Match(a) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p andPredicate) String() string {
+	if len(p) == 0 {
+		return "true"
+	}
+
+	strs := make([]string, len(p))
+	for i, q := range p {
+		strs[i] = q.String()
+		// or binds more loosely than and
+		if _, ok := q.(orPredicate); ok && len(q.(orPredicate)) > 1 {
+			strs[i] = "(" + strs[i] + ")"
+		}
+	}
+
+	return strings.Join(strs, " and ")
+}
+
+type orPredicate []Predicate
+
+// Or matches the assets matched by any of ps. With no arguments, it matches nothing.
+func Or(ps ...Predicate) Predicate {
+	if len(ps) == 1 {
+		return ps[0]
+	}
+
+	return orPredicate(ps)
+}
+
+func (p orPredicate) Match(a AssetDescription) bool {
+	for _, q := range p {
+		if q.Match(a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p orPredicate) String() string {
+	if len(p) == 0 {
+		return "false"
+	}
+
+	strs := make([]string, len(p))
+	for i, q := range p {
+		strs[i] = q.String()
+	}
+
+	return strings.Join(strs, " or ")
+}
+
+type notPredicate struct {
+	p Predicate
+}
+
+// Not matches the assets that p doesn't.
+func Not(p Predicate) Predicate {
+	return notPredicate{p}
+}
+
+func (p notPredicate) Match(a AssetDescription) bool {
+	return !p.p.Match(a)
+}
+
+func (p notPredicate) String() string {
+	switch q := p.p.(type) {
+	case andPredicate:
+		if len(q) > 1 {
+			return "not (" + q.String() + ")"
+		}
+	case orPredicate:
+		if len(q) > 1 {
+			return "not (" + q.String() + ")"
+		}
+	}
+
+	return "not " + p.p.String()
+}
+
+type flagPredicate struct {
+	name string
+	fn   func(a AssetDescription) bool
+}
+
+func (p flagPredicate) Match(a AssetDescription) bool {
+	return p.fn(a)
+}
+
+func (p flagPredicate) String() string {
+	return p.name
+}
+
+var (
+	// IsArchived matches assets that have been archived by their owner.
+	IsArchived Predicate = flagPredicate{"archived", func(a AssetDescription) bool { return a.IsArchived }}
+	// IsCopyrightProtected matches assets flagged as copyright protected.
+	IsCopyrightProtected Predicate = flagPredicate{"copyright-protected", func(a AssetDescription) bool { return a.IsCopyrightProtected }}
+	// IsHashDynamic matches assets whose content hash is dynamic.
+	IsHashDynamic Predicate = flagPredicate{"hash-dynamic", func(a AssetDescription) bool { return a.IsHashDynamic }}
+	// IsErrored matches descriptions that came back with any error.
+	IsErrored Predicate = flagPredicate{"errored", func(a AssetDescription) bool { return a.Errors != nil }}
+)
+
+var flagPredicates = map[string]Predicate{
+	IsArchived.String():           IsArchived,
+	IsCopyrightProtected.String(): IsCopyrightProtected,
+	IsHashDynamic.String():        IsHashDynamic,
+	IsErrored.String():            IsErrored,
+	"true":                        And(),
+	"false":                       Or(),
+}
+
+type assetTypePredicate []AssetType
+
+// HasAssetType matches assets of any of the given types.
+func HasAssetType(types ...AssetType) Predicate {
+	return assetTypePredicate(types)
+}
+
+func (p assetTypePredicate) Match(a AssetDescription) bool {
+	for _, t := range p {
+		if a.AssetTypeID == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p assetTypePredicate) String() string {
+	strs := make([]string, len(p))
+	for i, t := range p {
+		txt, _ := t.MarshalText()
+		strs[i] = string(txt)
+	}
+
+	return "type:" + strings.Join(strs, ",")
+}
+
+type errorPredicate []int
+
+// HasErrorCode matches descriptions carrying an error with any of the given codes.
+func HasErrorCode(codes ...int) Predicate {
+	return errorPredicate(codes)
+}
+
+func (p errorPredicate) Match(a AssetDescription) bool {
+	for _, code := range p {
+		if a.Errors.Contains(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p errorPredicate) String() string {
+	strs := make([]string, len(p))
+	for i, code := range p {
+		strs[i] = strconv.Itoa(code)
+	}
+
+	return "error:" + strings.Join(strs, ",")
+}
+
+type formatPredicate []string
+
+// HasFormat matches assets offered in any of the given formats, compared case-insensitively.
+func HasFormat(formats ...string) Predicate {
+	return formatPredicate(formats)
+}
+
+func (p formatPredicate) Match(a AssetDescription) bool {
+	for _, loc := range a.Locations {
+		for _, format := range p {
+			if strings.EqualFold(loc.AssetFormat, format) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (p formatPredicate) String() string {
+	return "format:" + strings.Join(p, ",")
+}
+
+// ErrInvalidPredicate is returned, wrapped in a *SyntaxError, for expressions that ParsePredicate can't parse.
+var ErrInvalidPredicate = errors.New("invalid predicate")
+
+// SyntaxError describes an expression that couldn't be parsed as a Predicate, with Err set to ErrInvalidPredicate.
+type SyntaxError = ranges.SyntaxError
+
+// ParsePredicate parses an expression such as
+//
+//	type:Model,MeshPart and not (archived or copyright-protected)
+//
+// Terms are combined with and, or and not (or &, | and !), in increasing order of precedence, and grouped
+// with parentheses. The terms are:
+//
+//   - archived, copyright-protected, hash-dynamic: the asset has the given flag set
+//   - errored: the description came back with an error
+//   - type:T,...: the asset has any of the given types, by name or ID
+//   - error:C,...: the description came back with an error with any of the given codes
+//   - format:F,...: the asset is offered in any of the given formats
+//   - true, false
+//
+// The empty expression matches everything.
+func ParsePredicate(expr string) (Predicate, error) {
+	p := predicateParser{expr: expr}
+	p.next()
+	if p.tok == "" {
+		return And(), nil
+	}
+
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, p.errorf("unexpected token")
+	}
+
+	return pred, nil
+}
+
+type predicateParser struct {
+	expr string
+	pos  int
+	// tok is the current token and offset its position, with tok empty at the end of expr.
+	tok    string
+	offset int
+}
+
+func isWordChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '/'
+}
+
+// next advances to the next token: a word, or one of the punctuation characters ( ) ! & | : ,
+func (p *predicateParser) next() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t' || p.expr[p.pos] == '\n') {
+		p.pos++
+	}
+
+	p.offset = p.pos
+	if p.pos >= len(p.expr) {
+		p.tok = ""
+		return
+	}
+
+	if !isWordChar(p.expr[p.pos]) {
+		p.pos++
+	} else {
+		for p.pos < len(p.expr) && isWordChar(p.expr[p.pos]) {
+			p.pos++
+		}
+	}
+	p.tok = p.expr[p.offset:p.pos]
+}
+
+func (p *predicateParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{
+		Err:    ErrInvalidPredicate,
+		Text:   p.expr,
+		Offset: p.offset,
+		Token:  p.tok,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *predicateParser) is(words ...string) bool {
+	for _, w := range words {
+		if strings.EqualFold(p.tok, w) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *predicateParser) parseOr() (Predicate, error) {
+	var terms []Predicate
+	for {
+		term, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+
+		if !p.is("or", "|") {
+			return Or(terms...), nil
+		}
+		p.next()
+	}
+}
+
+func (p *predicateParser) parseAnd() (Predicate, error) {
+	var terms []Predicate
+	for {
+		term, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+
+		if !p.is("and", "&") {
+			return And(terms...), nil
+		}
+		p.next()
+	}
+}
+
+func (p *predicateParser) parseNot() (Predicate, error) {
+	if p.is("not", "!") {
+		p.next()
+		term, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not(term), nil
+	}
+
+	return p.parseTerm()
+}
+
+func (p *predicateParser) parseTerm() (Predicate, error) {
+	if p.tok == "(" {
+		p.next()
+		pred, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return pred, nil
+	}
+
+	if p.tok == "" || !isWordChar(p.tok[0]) || p.is("and", "or", "not") {
+		return nil, p.errorf("expected a term")
+	}
+
+	name := strings.ToLower(p.tok)
+	if pred, ok := flagPredicates[name]; ok {
+		p.next()
+		return pred, nil
+	}
+
+	switch name {
+	case "type":
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		types := make([]AssetType, len(values))
+		for i, v := range values {
+			if types[i], err = ParseAssetType(v.tok); err != nil {
+				return nil, &SyntaxError{Err: ErrInvalidPredicate, Text: p.expr, Offset: v.offset, Token: v.tok, Msg: "unknown asset type"}
+			}
+		}
+		return HasAssetType(types...), nil
+	case "error":
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		codes := make([]int, len(values))
+		for i, v := range values {
+			if codes[i], err = strconv.Atoi(v.tok); err != nil {
+				return nil, &SyntaxError{Err: ErrInvalidPredicate, Text: p.expr, Offset: v.offset, Token: v.tok, Msg: "expected an error code"}
+			}
+		}
+		return HasErrorCode(codes...), nil
+	case "format":
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		formats := make([]string, len(values))
+		for i, v := range values {
+			formats[i] = v.tok
+		}
+		return HasFormat(formats...), nil
+	default:
+		return nil, p.errorf("unknown term")
+	}
+}
+
+type value struct {
+	tok    string
+	offset int
+}
+
+// parseValues parses the :v1,v2,... following a term name.
+func (p *predicateParser) parseValues() ([]value, error) {
+	p.next()
+	if p.tok != ":" {
+		return nil, p.errorf("expected :")
+	}
+
+	var values []value
+	for {
+		p.next()
+		if p.tok == "" || !isWordChar(p.tok[0]) {
+			return nil, p.errorf("expected a value")
+		}
+		values = append(values, value{p.tok, p.offset})
+
+		p.next()
+		if p.tok != "," {
+			return values, nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package assetdelivery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPredicate(t *testing.T) {
+	model := AssetDescription{AssetID: 1, AssetTypeID: Model, Locations: Locations{{AssetFormat: "source"}}}
+	archivedAudio := AssetDescription{AssetID: 2, AssetTypeID: Audio, IsArchived: true}
+	protectedMesh := AssetDescription{AssetID: 3, AssetTypeID: Mesh, IsCopyrightProtected: true, IsHashDynamic: true}
+	missing := AssetDescription{AssetID: 4, Errors: Errors{{Code: 404, Message: "Asset not found"}}}
+	all := AssetDescriptions{model, archivedAudio, protectedMesh, missing}
+
+	t.Run("combinators", func(t *testing.T) {
+		assert.Equal(t, all, all.Filter(And()))
+		assert.Empty(t, all.Filter(Or()))
+		assert.Equal(t, AssetDescriptions{model, protectedMesh}, all.Filter(And(HasAssetType(Model, Mesh), Not(IsArchived))))
+		assert.Equal(t, AssetDescriptions{archivedAudio, protectedMesh}, all.Filter(Or(IsArchived, IsCopyrightProtected)))
+		assert.Equal(t, AssetDescriptions{missing}, all.Filter(HasErrorCode(403, 404)))
+		assert.Equal(t, AssetDescriptions{model}, all.Filter(HasFormat("Source")))
+		assert.Equal(t, AssetDescriptions{model, archivedAudio, protectedMesh}, all.DiscardErrored())
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		type testCase struct {
+			expr      string
+			expected  AssetDescriptions
+			canonical string
+		}
+
+		cases := []testCase{
+			{expr: "", expected: all, canonical: "true"},
+			{expr: "type:Model,mesh", expected: AssetDescriptions{model, protectedMesh}, canonical: "type:Model,Mesh"},
+			{expr: "type:3 | errored", expected: AssetDescriptions{archivedAudio, missing}, canonical: "type:Audio or errored"},
+			{expr: "not errored and not (archived or copyright-protected)", expected: AssetDescriptions{model}, canonical: "not errored and not (archived or copyright-protected)"},
+			{expr: "!hash-dynamic & !error:404", expected: AssetDescriptions{model, archivedAudio}, canonical: "not hash-dynamic and not error:404"},
+			{expr: "(archived or errored) and not type:Audio", expected: AssetDescriptions{missing}, canonical: "(archived or errored) and not type:Audio"},
+			{expr: "format:source or false", expected: AssetDescriptions{model}, canonical: "format:source or false"},
+			{expr: "ARCHIVED AND TRUE", expected: AssetDescriptions{archivedAudio}, canonical: "archived and true"},
+		}
+
+		for _, c := range cases {
+			p, err := ParsePredicate(c.expr)
+			require.NoError(t, err, c.expr)
+			assert.Equal(t, c.expected, all.Filter(p), c.expr)
+			assert.Equal(t, c.canonical, p.String(), c.expr)
+
+			reparsed, err := ParsePredicate(p.String())
+			require.NoError(t, err)
+			assert.Equal(t, p.String(), reparsed.String())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		type testCase struct {
+			expr   string
+			offset int
+			token  string
+		}
+
+		cases := []testCase{
+			{expr: "archived and", offset: 12, token: ""},
+			{expr: "type:Modle", offset: 5, token: "Modle"},
+			{expr: "error:abc", offset: 6, token: "abc"},
+			{expr: "(archived", offset: 9, token: ""},
+			{expr: "archived)", offset: 8, token: ")"},
+			{expr: "deleted", offset: 0, token: "deleted"},
+			{expr: "type", offset: 4, token: ""},
+			{expr: "type:,", offset: 5, token: ","},
+			{expr: "archived errored", offset: 9, token: "errored"},
+		}
+
+		for _, c := range cases {
+			_, err := ParsePredicate(c.expr)
+			require.Error(t, err, c.expr)
+			assert.ErrorIs(t, err, ErrInvalidPredicate)
+
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr))
+			assert.Equal(t, c.offset, syntaxErr.Offset, c.expr)
+			assert.Equal(t, c.token, syntaxErr.Token, c.expr)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package assetdelivery
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// RetryPolicy decides how a Client retries requests that were throttled, timed out at the gateway,
+// or failed to reach the API at all. Waits between attempts follow decorrelated exponential backoff
+// with jitter, unless the API asks for a specific wait with a Retry-After header.
+// The resty client passed to NewClient shouldn't retry requests itself.
+type RetryPolicy struct {
+	// MaxRetries is the most times a request is retried. Zero disables retries.
+	MaxRetries int
+	// BaseDelay is the shortest wait between attempts.
+	BaseDelay time.Duration
+	// MaxDelay caps each backoff wait. It doesn't cap waits asked for by Retry-After.
+	MaxDelay time.Duration
+	// MaxRetryTime caps the total time spent waiting to retry a single call, if positive.
+	// Retries are also given up if the wait would outlast the context's deadline.
+	MaxRetryTime time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy of a Client created without WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:   3,
+	BaseDelay:    500 * time.Millisecond,
+	MaxDelay:     30 * time.Second,
+	MaxRetryTime: time.Minute,
+}
+
+// WithRetryPolicy sets the RetryPolicy of the Client.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = p
+	}
+}
+
+// CallStats describes the attempts made by a single call to the Client.
+type CallStats struct {
+	// Retries is the number of requests sent after the first.
+	Retries int
+	// Waited is the total time spent waiting between attempts.
+	Waited time.Duration
+}
+
+// backoff returns the wait following a wait of prev, or the first wait if prev is zero:
+// a random duration between BaseDelay and three times prev, capped at MaxDelay.
+func (p RetryPolicy) backoff(prev time.Duration) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = time.Millisecond
+	}
+	if prev < base {
+		prev = base
+	}
+
+	wait := base + time.Duration(rand.Int63n(int64(3*prev-base)+1))
+	if p.MaxDelay > 0 && wait > p.MaxDelay {
+		wait = p.MaxDelay
+	}
+
+	return wait
+}
+
+// retryAfter returns the wait asked for by the Retry-After header of resp, or zero if there is none.
+func retryAfter(resp *resty.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	header := resp.Header().Get("Retry-After")
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+
+	return 0
+}
+
+// execute sends a request with send, retrying according to the Client's RetryPolicy, and returns the last response.
+// If stats is non-nil, it is filled in with the attempts made.
+func (c *Client) execute(ctx context.Context, stats *CallStats, send func() (*resty.Response, error)) (*resty.Response, error) {
+	if stats == nil {
+		stats = &CallStats{}
+	}
+
+	var prev time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, err := send()
+
+		// requests that didn't reach the API at all are retried, unless the caller gave up on them
+		// or there are no credentials to send them with
+		retryable := err != nil && ctx.Err() == nil && !errors.Is(err, ErrNoCredentials)
+		if err == nil {
+			retryable = isRetryable(statusError(resp.StatusCode()))
+		}
+		if !retryable || attempt >= c.retry.MaxRetries {
+			return resp, err
+		}
+
+		wait := c.retry.backoff(prev)
+		prev = wait
+		if after := retryAfter(resp); after > 0 {
+			wait = after
+		}
+
+		if c.retry.MaxRetryTime > 0 && stats.Waited+wait > c.retry.MaxRetryTime {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+
+		stats.Retries++
+		stats.Waited += wait
+	}
+}
@@ -0,0 +1,114 @@
+package assetdelivery_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+)
+
+func TestRetry(t *testing.T) {
+	ids := []int64{3, 6, 9}
+	fast := assetdelivery.WithRetryPolicy(assetdelivery.RetryPolicy{
+		MaxRetries: 3,
+		BaseDelay:  time.Millisecond,
+		MaxDelay:   5 * time.Millisecond,
+	})
+
+	batch := func(ctx context.Context, cl *assetdelivery.Client) (assetdelivery.CallStats, error) {
+		var stats assetdelivery.CallStats
+		_, err := cl.Batch(ctx, ids, &assetdelivery.BatchOptions{Stats: &stats})
+		return stats, err
+	}
+
+	t.Run("recovers from throttling", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		s.Fail(http.StatusTooManyRequests, 1)
+		s.Fail(http.StatusGatewayTimeout, 1)
+
+		stats, err := batch(context.Background(), s.AssetDeliveryClient(fast))
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats.Retries)
+		// decorrelated jitter keeps every wait between the base and max delays
+		assert.GreaterOrEqual(t, stats.Waited, 2*time.Millisecond)
+		assert.LessOrEqual(t, stats.Waited, 10*time.Millisecond)
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		s.Fail(http.StatusGatewayTimeout, 5)
+
+		stats, err := batch(context.Background(), s.AssetDeliveryClient(fast))
+		assert.ErrorIs(t, err, assetdelivery.ErrGatewayTimeout)
+		assert.Equal(t, 3, stats.Retries)
+		assert.Equal(t, 4, s.Requests())
+	})
+
+	t.Run("doesn't retry when blocked", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		s.Fail(http.StatusForbidden, 1)
+
+		stats, err := batch(context.Background(), s.AssetDeliveryClient(fast))
+		assert.ErrorIs(t, err, assetdelivery.ErrForbidden)
+		assert.Zero(t, stats.Retries)
+	})
+
+	t.Run("honors retry-after", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithRetryAfter(time.Second))
+		defer s.Close()
+		s.Fail(http.StatusTooManyRequests, 1)
+
+		t0 := time.Now()
+		stats, err := batch(context.Background(), s.AssetDeliveryClient(fast))
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, stats.Waited)
+		assert.GreaterOrEqual(t, time.Since(t0), time.Second)
+	})
+
+	t.Run("stops at the deadline", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithRetryAfter(time.Second))
+		defer s.Close()
+		s.Fail(http.StatusTooManyRequests, 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		t0 := time.Now()
+		stats, err := batch(ctx, s.AssetDeliveryClient(fast))
+		assert.ErrorIs(t, err, assetdelivery.ErrRateLimited)
+		assert.Zero(t, stats.Retries)
+		assert.Less(t, time.Since(t0), 200*time.Millisecond)
+	})
+
+	t.Run("caps total retry time", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithRetryAfter(time.Second))
+		defer s.Close()
+		s.Fail(http.StatusTooManyRequests, 1)
+
+		stats, err := batch(context.Background(), s.AssetDeliveryClient(assetdelivery.WithRetryPolicy(assetdelivery.RetryPolicy{
+			MaxRetries:   3,
+			BaseDelay:    time.Millisecond,
+			MaxRetryTime: 500 * time.Millisecond,
+		})))
+		assert.ErrorIs(t, err, assetdelivery.ErrRateLimited)
+		assert.Zero(t, stats.Retries)
+	})
+
+	t.Run("retries unreachable servers", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		cl := s.AssetDeliveryClient(fast)
+		s.Close()
+
+		stats, err := batch(context.Background(), cl)
+		assert.Error(t, err)
+		assert.Equal(t, 3, stats.Retries)
+	})
+}
@@ -0,0 +1,62 @@
+package assetdelivery
+
+import (
+	"context"
+	"errors"
+)
+
+// versionPageSize is the number of versions of an asset that Versions requests at once.
+// Most assets have only a few versions, so a page usually covers all of them.
+const versionPageSize = 16
+
+// DefaultMaxVersions is the most versions of an asset that Versions looks up unless told otherwise.
+const DefaultMaxVersions = 1024
+
+// Versions looks up the descriptions of every version of the asset id, oldest first, with Version filled in.
+// The versions are requested a page at a time, waiting on opts.Limiter before each, until a whole page isn't
+// found, which is taken to be past the latest, or until opts.MaxVersions. Versions that aren't found before
+// then, e.g. by having been deleted, are left out; versions that fail otherwise, e.g. by being moderated,
+// are returned with their errors.
+func (c *Client) Versions(ctx context.Context, id int64, opts *BatchAllOptions) (AssetDescriptions, error) {
+	maxVersions := opts.MaxVersions
+	if maxVersions <= 0 {
+		maxVersions = DefaultMaxVersions
+	}
+
+	var versions AssetDescriptions
+	for first := 1; first <= maxVersions; first += versionPageSize {
+		n := versionPageSize
+		if left := maxVersions - first + 1; n > left {
+			n = left
+		}
+		items := make(AssetRequestItems, n)
+		for i := range items {
+			items[i] = AssetRequestItem{AssetID: id, Version: first + i}
+		}
+
+		if opts.Limiter != nil {
+			if err := opts.Limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		page, err := c.BatchItems(ctx, items, &opts.BatchOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, description := range page {
+			if errors.Is(description.Err(), ErrNotFound) {
+				continue
+			}
+			found = true
+			versions = append(versions, description)
+		}
+		if !found {
+			break
+		}
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,82 @@
+package assetdelivery_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+)
+
+func TestVersions(t *testing.T) {
+	type testCase struct {
+		id       int64
+		versions int
+	}
+
+	cases := []testCase{
+		{id: 1, versions: 0},
+		{id: 3, versions: 2},
+		{id: 6, versions: 3},
+		{id: 9, versions: 1},
+		// more versions than fit in a page
+		{id: 12, versions: 40},
+	}
+
+	s := fakeassetdelivery.NewServer(fakeassetdelivery.WithVersions(func(id int64) int {
+		if id == 12 {
+			return 40
+		}
+		return fakeassetdelivery.SyntheticVersions(id)
+	}))
+	defer s.Close()
+
+	var stats assetdelivery.CallStats
+	opts := &assetdelivery.BatchAllOptions{BatchOptions: assetdelivery.BatchOptions{SkipSigningScripts: true, Stats: &stats}}
+	for _, c := range cases {
+		versions, err := s.AssetDeliveryClient().Versions(context.Background(), c.id, opts)
+		require.NoError(t, err)
+		require.Len(t, versions, c.versions, "asset %d", c.id)
+		for i, version := range versions {
+			assert.Equal(t, c.id, version.AssetID)
+			assert.Equal(t, i+1, version.Version)
+			assert.NoError(t, version.Err())
+		}
+	}
+
+	t.Run("locations differ by version", func(t *testing.T) {
+		versions, err := s.AssetDeliveryClient().Versions(context.Background(), 6, opts)
+		require.NoError(t, err)
+		require.Len(t, versions, 3)
+		assert.NotEqual(t, versions[0].Etag(), versions[1].Etag())
+	})
+
+	t.Run("skips deleted versions", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(
+			fakeassetdelivery.WithVersions(func(int64) int { return 40 }),
+			fakeassetdelivery.WithDeletedVersions(func(_ int64, version int) bool { return version == 2 || version == 17 }))
+		defer s.Close()
+
+		versions, err := s.AssetDeliveryClient().Versions(context.Background(), 12, opts)
+		require.NoError(t, err)
+		require.Len(t, versions, 38)
+		assert.Equal(t, 1, versions[0].Version)
+		assert.Equal(t, 3, versions[1].Version)
+		assert.Equal(t, 18, versions[15].Version)
+		assert.Equal(t, 40, versions[37].Version)
+	})
+
+	t.Run("stops at the most versions", func(t *testing.T) {
+		capped := *opts
+		capped.MaxVersions = 20
+		requests := s.Requests()
+
+		versions, err := s.AssetDeliveryClient().Versions(context.Background(), 12, &capped)
+		require.NoError(t, err)
+		require.Len(t, versions, 20)
+		assert.Equal(t, 20, versions[19].Version)
+		assert.Equal(t, 2, s.Requests()-requests)
+	})
+}
@@ -5,17 +5,93 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/proxypool"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/sampling"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport/profilestats"
 )
 
 type Request struct {
 	Ranges      ranges.Ranges `json:"ranges"`
 	Concurrency int           `json:"concurrency,omitempty"`
+	// Order is the order in which Ranges are indexed. It defaults to descending.
+	Order ranges.Order `json:"order"`
+	// Sample looks up the IDs in Ranges without downloading anything, and reports the asset types found
+	// in Response.Samples. The Ranges are usually a sample drawn by sampling.Plan.
+	Sample bool `json:"sample,omitempty"`
+
+	// AssetTypes are the asset types to download. If both AssetTypes and ExcludeAssetTypes are empty,
+	// only models are downloaded; if just AssetTypes is empty, every type not excluded is downloaded.
+	AssetTypes []assetdelivery.AssetType `json:"asset_types,omitempty"`
+	// ExcludeAssetTypes are asset types never to download, even if listed in AssetTypes.
+	ExcludeAssetTypes []assetdelivery.AssetType `json:"exclude_asset_types,omitempty"`
+	// SkipArchived skips assets that have been archived by their owner.
+	SkipArchived bool `json:"skip_archived,omitempty"`
+	// SkipCopyrightProtected skips assets flagged as copyright protected.
+	SkipCopyrightProtected bool `json:"skip_copyright_protected,omitempty"`
+	// Filter is an expression further restricting the assets to download, as parsed by assetdelivery.ParsePredicate,
+	// e.g. "not hash-dynamic and format:source".
+	Filter string `json:"filter,omitempty"`
+	// Versions downloads every version of the selected assets rather than just the latest, storing each under
+	// <id>/<version>.gz, and lists them in Response.Versions.
+	Versions bool `json:"versions,omitempty"`
+	// Formats are the asset formats to download each asset in, most preferred first, e.g. "source".
+	// An asset in none of them is downloaded from its first location.
+	Formats []string `json:"formats,omitempty"`
+	// AllFormats downloads every format that each asset is offered in, rather than just the preferred one.
+	AllFormats bool `json:"all_formats,omitempty"`
+
+	// TLSProfiles are the ClientHellos to reach the Asset Delivery API with, by name, e.g. "chrome" or "firefox-65";
+	// see transport.ParseProfile. They default to a randomized ClientHello.
+	TLSProfiles []string `json:"tls_profiles,omitempty"`
+	// TLSStrategy picks the profile of each connection: "fixed", "round-robin" or "weighted" by recent success.
+	// It defaults to fixed for a single profile, and round-robin otherwise.
+	TLSStrategy string `json:"tls_strategy,omitempty"`
+	// ProxyRotation is how often requests move on to the next indexer proxy: "request" (the default) or "session".
+	ProxyRotation string `json:"proxy_rotation,omitempty"`
+	// ProxyRate is the most requests per second through each indexer proxy. Zero doesn't limit them.
+	ProxyRate float64 `json:"proxy_rate,omitempty"`
+}
+
+// DefaultAssetTypes are the asset types downloaded by a Request that doesn't select any.
+var DefaultAssetTypes = []assetdelivery.AssetType{assetdelivery.Model}
+
+// Predicate returns the Predicate selecting the assets that r downloads: those of the selected asset types,
+// passing the archived and copyright protected filters, and matching Filter.
+func (r Request) Predicate() (assetdelivery.Predicate, error) {
+	filter, err := assetdelivery.ParsePredicate(r.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var preds []assetdelivery.Predicate
+	if len(r.AssetTypes) > 0 {
+		preds = append(preds, assetdelivery.HasAssetType(r.AssetTypes...))
+	} else if len(r.ExcludeAssetTypes) == 0 {
+		preds = append(preds, assetdelivery.HasAssetType(DefaultAssetTypes...))
+	}
+	if len(r.ExcludeAssetTypes) > 0 {
+		preds = append(preds, assetdelivery.Not(assetdelivery.HasAssetType(r.ExcludeAssetTypes...)))
+	}
+	if r.SkipArchived {
+		preds = append(preds, assetdelivery.Not(assetdelivery.IsArchived))
+	}
+	if r.SkipCopyrightProtected {
+		preds = append(preds, assetdelivery.Not(assetdelivery.IsCopyrightProtected))
+	}
+	if r.Filter != "" {
+		preds = append(preds, filter)
+	}
+
+	return assetdelivery.And(preds...), nil
 }
 
 type Response struct {
@@ -25,6 +101,87 @@ type Response struct {
 	Total                int    `json:"total"`
 	DurationMilliseconds int    `json:"duration_ms"`
 	Error                string `json:"error,omitempty"`
+	// Retries is the number of times Asset Delivery API requests were retried after being throttled or failing.
+	Retries int `json:"retries,omitempty"`
+
+	// Indexed holds the IDs that were successfully looked up in the Asset Delivery API.
+	Indexed *ranges.Bitmap `json:"indexed,omitempty"`
+	// Found holds the indexed IDs that matched the request and were queued for download.
+	Found *ranges.Bitmap `json:"found,omitempty"`
+	// Downloaded holds the found IDs that were stored.
+	Downloaded *ranges.Bitmap `json:"downloaded,omitempty"`
+	// Failed holds the IDs that couldn't be indexed or stored.
+	Failed *ranges.Bitmap `json:"failed,omitempty"`
+
+	// Samples holds the asset types found by a Request with Sample set.
+	Samples *sampling.Tally `json:"samples,omitempty"`
+	// Versions holds the versions found by a Request with Versions set.
+	Versions []AssetVersion `json:"versions,omitempty"`
+	// Profiles counts the outcomes of Asset Delivery API requests by the TLS profile they were made with.
+	Profiles map[string]profilestats.Stats `json:"profiles,omitempty"`
+	// Proxies summarizes the indexer proxies that requests went through, as they were when the job ended.
+	Proxies []proxypool.Stats `json:"proxies,omitempty"`
+}
+
+// AssetVersion is a version of an asset found by a Request with Versions set.
+type AssetVersion struct {
+	AssetID int64 `json:"asset_id"`
+	Version int   `json:"version"`
+	// Hash is the etag of the version's contents, so that versions with the same contents can be told apart
+	// without downloading them.
+	Hash string `json:"hash"`
+	// Stored is whether the version was stored under <id>/<version>.gz.
+	Stored bool `json:"stored"`
+}
+
+// ErrRateLimited is returned by Sync, wrapped in an *InvokeError, when DigitalOcean throttles function invocations.
+var ErrRateLimited = errors.New("function invocations rate limited")
+
+// InvokeError is returned by Sync when doctl fails to invoke the function.
+type InvokeError struct {
+	Err    error
+	Stderr string
+	// StatusCode is the HTTP status that the invocation failed with, or 0 if it didn't get a response.
+	StatusCode int
+}
+
+// newInvokeError returns the error for an invocation that failed with err, classifying it by the status
+// that doctl reports on stderr, as in
+//
+//	Error: POST https://faas-nyc1-2ef2e6cc.doserverless.co/api/v1/namespaces/_/actions/scraper/sync: 429 Too Many Requests
+func newInvokeError(err error, stderr string) *InvokeError {
+	e := &InvokeError{Err: err, Stderr: stderr}
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "Error: ") {
+			continue
+		}
+
+		i := strings.LastIndex(line, ": ")
+		status := strings.Fields(line[i+2:])
+		if len(status) == 0 {
+			continue
+		}
+		if code, err := strconv.Atoi(status[0]); err == nil && code >= 100 && code < 600 {
+			e.StatusCode = code
+			break
+		}
+	}
+
+	return e
+}
+
+func (e *InvokeError) Error() string {
+	return fmt.Sprintf("%s\n%s", e.Err, e.Stderr)
+}
+
+func (e *InvokeError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrRateLimited if the invocation was throttled.
+func (e *InvokeError) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
 }
 
 type Client struct{}
@@ -61,7 +218,7 @@ func (c *Client) Sync(ctx context.Context, req Request) (*Response, error) {
 	if err != nil {
 		var exitError *exec.ExitError
 		if errors.As(err, &exitError) {
-			return nil, fmt.Errorf("%w\n%s", err, fmt.Errorf(string(exitError.Stderr)))
+			return nil, newInvokeError(err, string(exitError.Stderr))
 		}
 
 		return nil, err
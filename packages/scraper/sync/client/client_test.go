@@ -0,0 +1,66 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+)
+
+func TestRequestPredicate(t *testing.T) {
+	model := assetdelivery.AssetDescription{AssetID: 1, AssetTypeID: assetdelivery.Model}
+	archivedModel := assetdelivery.AssetDescription{AssetID: 2, AssetTypeID: assetdelivery.Model, IsArchived: true}
+	audio := assetdelivery.AssetDescription{AssetID: 3, AssetTypeID: assetdelivery.Audio, IsHashDynamic: true}
+	decal := assetdelivery.AssetDescription{AssetID: 4, AssetTypeID: assetdelivery.Decal, IsCopyrightProtected: true}
+	all := assetdelivery.AssetDescriptions{model, archivedModel, audio, decal}
+
+	type testCase struct {
+		req      Request
+		expected assetdelivery.AssetDescriptions
+	}
+
+	cases := []testCase{
+		{req: Request{}, expected: assetdelivery.AssetDescriptions{model, archivedModel}},
+		{req: Request{SkipArchived: true}, expected: assetdelivery.AssetDescriptions{model}},
+		{req: Request{AssetTypes: []assetdelivery.AssetType{assetdelivery.Audio, assetdelivery.Decal}}, expected: assetdelivery.AssetDescriptions{audio, decal}},
+		{req: Request{ExcludeAssetTypes: []assetdelivery.AssetType{assetdelivery.Model}}, expected: assetdelivery.AssetDescriptions{audio, decal}},
+		{req: Request{ExcludeAssetTypes: []assetdelivery.AssetType{assetdelivery.Model}, SkipCopyrightProtected: true}, expected: assetdelivery.AssetDescriptions{audio}},
+		{req: Request{ExcludeAssetTypes: []assetdelivery.AssetType{assetdelivery.Model}, Filter: "not hash-dynamic"}, expected: assetdelivery.AssetDescriptions{decal}},
+	}
+
+	for _, c := range cases {
+		p, err := c.req.Predicate()
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, all.Filter(p), p.String())
+	}
+
+	_, err := Request{Filter: "type:"}.Predicate()
+	assert.ErrorIs(t, err, assetdelivery.ErrInvalidPredicate)
+}
+
+func TestInvokeError(t *testing.T) {
+	exit := errors.New("exit status 1")
+	throttled := newInvokeError(exit, "Error: POST https://faas-nyc1-2ef2e6cc.doserverless.co/api/v1/namespaces/_/actions/scraper/sync: 429 Too Many Requests\n")
+	assert.Equal(t, http.StatusTooManyRequests, throttled.StatusCode)
+	assert.ErrorIs(t, fmt.Errorf("sync range: %w", throttled), ErrRateLimited)
+
+	type testCase struct {
+		stderr string
+		status int
+	}
+
+	for _, tc := range []testCase{
+		{stderr: "Error: unable to read payload file", status: 0},
+		{stderr: "Error: POST https://faas-nyc1-2ef2e6cc.doserverless.co/api/v1/namespaces/_/actions/scraper/sync: 502 Bad Gateway", status: http.StatusBadGateway},
+		// the text alone doesn't make it throttled
+		{stderr: "Error: function failed: Too Many Requests to the Asset Delivery API", status: 0},
+	} {
+		err := newInvokeError(exit, tc.stderr)
+		assert.Equal(t, tc.status, err.StatusCode, tc.stderr)
+		assert.NotErrorIs(t, err, ErrRateLimited, tc.stderr)
+	}
+}
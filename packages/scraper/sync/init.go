@@ -1,22 +1,29 @@
 package main
 
-import "os"
+import (
+	"errors"
+	"os"
+)
 
-func init() {
+// loadConfig reads the S3 settings from the environment. It is called by Main rather than at init,
+// so that the package can be tested without them.
+func loadConfig() error {
 	key = os.Getenv("WASABI_ACCESS_KEY")
 	if key == "" {
-		panic("no key provided")
+		return errors.New("no key provided")
 	}
 	secret = os.Getenv("WASABI_SECRET_KEY")
 	if secret == "" {
-		panic("no secret provided")
+		return errors.New("no secret provided")
 	}
 	bucket = os.Getenv("WASABI_BUCKET")
 	if bucket == "" {
-		panic("no bucket provided")
+		return errors.New("no bucket provided")
 	}
 	region = os.Getenv("WASABI_REGION")
 	if region == "" {
-		panic("no region provided")
+		return errors.New("no region provided")
 	}
+
+	return nil
 }
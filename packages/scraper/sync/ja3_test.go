@@ -10,6 +10,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
 	"golang.org/x/sync/errgroup"
 )
@@ -22,6 +23,10 @@ func TestJa3(t *testing.T) {
 
 	rngs := ranges.Ranges{rng}
 	eg, eCtx := errgroup.WithContext(context.TODO())
-	eg.Go(func() error { return indexLoop(eCtx, eg, rngs, items, time.Second/256) })
+	indexer, _, err := newIndexer(client.Request{})
+	require.NoError(t, err)
+	eg.Go(func() error {
+		return indexLoop(eCtx, indexer, client.Request{Ranges: rngs}, items, &outcomes{}, time.Second/256)
+	})
 	require.NoError(t, eg.Wait())
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+)
+
+// outcomes records which IDs reached each stage of a sync, so that the orchestrator can store them per ID.
+type outcomes struct {
+	mu sync.Mutex
+	// indexed holds the IDs whose batch request succeeded.
+	indexed ranges.Bitmap
+	// found holds the indexed IDs that were queued for download.
+	found ranges.Bitmap
+	// downloaded holds the found IDs that were uploaded to S3.
+	downloaded ranges.Bitmap
+	// failed holds the IDs whose batch request, download or upload failed.
+	failed ranges.Bitmap
+	// retries counts the times batch requests were retried.
+	retries int
+	// versions holds the versions found when crawling versions, indexed by versionIndex.
+	versions     []client.AssetVersion
+	versionIndex map[versionKey]int
+}
+
+type versionKey struct {
+	id      int64
+	version int
+}
+
+func (o *outcomes) add(set *ranges.Bitmap, ids ...int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, id := range ids {
+		set.Add(id)
+	}
+}
+
+func (o *outcomes) addRanges(set *ranges.Bitmap, rngs ranges.Ranges) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	set.AddRanges(rngs)
+}
+
+// markDownloaded moves id from failed to downloaded.
+func (o *outcomes) markDownloaded(id int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.failed.Remove(id)
+	o.downloaded.Add(id)
+}
+
+func (o *outcomes) addRetries(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.retries += n
+}
+
+// addVersion records a version found when crawling versions, as not stored yet.
+func (o *outcomes) addVersion(item assetdelivery.AssetDescription) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.versionIndex == nil {
+		o.versionIndex = make(map[versionKey]int)
+	}
+	o.versionIndex[versionKey{item.AssetID, item.Version}] = len(o.versions)
+	o.versions = append(o.versions, client.AssetVersion{
+		AssetID: item.AssetID,
+		Version: item.Version,
+		Hash:    item.Etag(),
+	})
+}
+
+// markVersionStored marks a version recorded by addVersion as stored.
+func (o *outcomes) markVersionStored(id int64, version int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if i, ok := o.versionIndex[versionKey{id, version}]; ok {
+		o.versions[i].Stored = true
+	}
+}
@@ -0,0 +1,442 @@
+// Package proxypool spreads requests between proxies, each under its own rate limit, and sets aside the ones
+// whose exits get blocked or fail.
+package proxypool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// SessionPlaceholder marks where a proxy URL template takes a session ID, e.g. in the username
+// "user-session-{session}" of providers that pin an exit IP to each session.
+const SessionPlaceholder = "{session}"
+
+// ErrNoProxies is returned by New when given no proxies.
+var ErrNoProxies = errors.New("no proxies")
+
+// Rotation is how often a Pool moves on to its next proxy.
+type Rotation string
+
+const (
+	// PerRequest sends every request through the next proxy.
+	PerRequest Rotation = "request"
+	// PerSession sends Options.SessionRequests requests in a row through each proxy, so that they look like
+	// a browsing session from a single IP.
+	PerSession Rotation = "session"
+)
+
+// ParseRotation returns the Rotation with the given name, defaulting to PerRequest.
+func ParseRotation(name string) (Rotation, error) {
+	switch r := Rotation(strings.ToLower(name)); r {
+	case "":
+		return PerRequest, nil
+	case PerRequest, PerSession:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown proxy rotation %q", name)
+	}
+}
+
+// Options configure a Pool. Their zero values select the defaults.
+type Options struct {
+	Rotation Rotation
+	// SessionRequests is the number of requests in a session with PerSession rotation. It defaults to 50.
+	SessionRequests int
+	// Sessions is the number of sessions that each proxy URL template is expanded into. It defaults to 8.
+	Sessions int
+	// Rate is the most requests per second through each proxy, and Burst the most at once.
+	// A zero Rate doesn't limit them.
+	Rate  float64
+	Burst int
+	// Window is the number of recent requests through a proxy that its block rate is measured over. It defaults to 20.
+	Window int
+	// MaxBlockRate is the share of the window that, blocked with 403 and 429 responses or failed by the proxy,
+	// gets a proxy quarantined. It defaults to 0.5.
+	MaxBlockRate float64
+	// CoolDown is how long a proxy stays quarantined. It defaults to 5 minutes.
+	CoolDown time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.Rotation == "" {
+		o.Rotation = PerRequest
+	}
+	if o.SessionRequests <= 0 {
+		o.SessionRequests = 50
+	}
+	if o.Sessions <= 0 {
+		o.Sessions = 8
+	}
+	if o.Burst <= 0 {
+		o.Burst = 1
+	}
+	if o.Window <= 0 {
+		o.Window = 20
+	}
+	if o.MaxBlockRate <= 0 {
+		o.MaxBlockRate = 0.5
+	}
+	if o.CoolDown <= 0 {
+		o.CoolDown = 5 * time.Minute
+	}
+}
+
+// Split splits a list of proxy URLs separated by commas or whitespace.
+func Split(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
+
+// Stats summarizes a proxy of a Pool.
+type Stats struct {
+	// Proxy is the URL of the proxy, without its password.
+	Proxy     string `json:"proxy"`
+	Requests  int    `json:"requests"`
+	Throttled int    `json:"throttled"`
+	Forbidden int    `json:"forbidden"`
+	Errors    int    `json:"errors"`
+	// Quarantines counts the times the proxy was set aside for blocked or failed requests.
+	Quarantines int `json:"quarantines,omitempty"`
+	// Renewals counts the times the proxy, from a template, moved on to a new session for blocked or failed requests.
+	Renewals int `json:"renewals,omitempty"`
+	// Quarantined is whether the proxy is set aside now.
+	Quarantined bool `json:"quarantined,omitempty"`
+}
+
+// Pool is an http.RoundTripper that sends requests through its proxies in turn, skipping those in quarantine.
+//
+// A proxy whose recent requests were mostly blocked or failed, e.g. because it is down or refuses to tunnel,
+// is quarantined for a cool-down, or, if it came from a template,
+// moved on to a new session and so a new exit. The last healthy proxy is never quarantined, since there'd be
+// nothing to rotate to.
+type Pool struct {
+	opts         Options
+	newTransport func(proxy *url.URL) (http.RoundTripper, error)
+	now          func() time.Time
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	proxies []*proxy
+	next    int
+	// left is the number of requests left in the session of proxies[next-1], with PerSession rotation
+	left int
+}
+
+type proxy struct {
+	// template is the URL template that the proxy was made from, if any
+	template string
+	session  *session
+	limiter  *rate.Limiter
+	// recent holds whether each of the last requests was blocked or failed, oldest first
+	recent []bool
+	stats  Stats
+	until  time.Time
+}
+
+// session is the transport of a proxy for one proxy URL.
+type session struct {
+	transport http.RoundTripper
+	// inFlight counts the requests through transport whose responses aren't done with
+	inFlight int
+	// retired is whether the proxy moved on to a new session, so that transport's connections are closed
+	// once no request is in flight
+	retired bool
+}
+
+// New returns a Pool between proxies, which are URLs or templates of URLs with SessionPlaceholder in them.
+// Requests go through each proxy with the round tripper returned by newTransport for its URL.
+func New(proxies []string, opts Options, newTransport func(proxy *url.URL) (http.RoundTripper, error)) (*Pool, error) {
+	if len(proxies) == 0 {
+		return nil, ErrNoProxies
+	}
+
+	opts.setDefaults()
+	p := &Pool{
+		opts:         opts,
+		newTransport: newTransport,
+		now:          time.Now,
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	limit := rate.Inf
+	if opts.Rate > 0 {
+		limit = rate.Limit(opts.Rate)
+	}
+	for _, s := range proxies {
+		n, template := 1, ""
+		if strings.Contains(s, SessionPlaceholder) {
+			n, template = opts.Sessions, s
+		}
+
+		for i := 0; i < n; i++ {
+			px := &proxy{template: template, limiter: rate.NewLimiter(limit, opts.Burst)}
+			if err := p.connect(px, s); err != nil {
+				return nil, err
+			}
+			p.proxies = append(p.proxies, px)
+		}
+	}
+
+	return p, nil
+}
+
+// connect points px at the proxy URL s, filling in a new session if s is a template.
+func (p *Pool) connect(px *proxy, s string) error {
+	if px.template != "" {
+		s = strings.ReplaceAll(px.template, SessionPlaceholder, fmt.Sprintf("%08x", p.rand.Uint32()))
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		// the URL may have a password in it
+		return errors.New("parse proxy URL: invalid URL")
+	}
+	transport, err := p.newTransport(u)
+	if err != nil {
+		return err
+	}
+
+	if old := px.session; old != nil {
+		old.retired = true
+		old.closeIfDone()
+	}
+	px.session = &session{transport: transport}
+	px.recent = px.recent[:0]
+	px.stats.Proxy = u.Redacted()
+
+	return nil
+}
+
+// closeIfDone closes the idle connections of a retired session once its last request is done. p.mu must be held.
+func (s *session) closeIfDone() {
+	if !s.retired || s.inFlight > 0 {
+		return
+	}
+
+	if closer, ok := s.transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	px, s, err := p.pick(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.transport.RoundTrip(req)
+	p.observe(px, s, resp, err)
+	if err != nil {
+		p.release(s)
+		return nil, err
+	}
+
+	// the connection stays busy until the body is closed
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: func() { p.release(s) }}
+	return resp, nil
+}
+
+// release marks a request through s as done.
+func (p *Pool) release(s *session) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s.inFlight--
+	s.closeIfDone()
+}
+
+// releaseBody calls release once it is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// pick returns the proxy for the next request and its session, once its rate limit allows it: with PerRequest
+// rotation, the next one that is available right away, or else the one available soonest.
+// The request is counted in flight through the session until it is released.
+func (p *Pool) pick(ctx context.Context) (*proxy, *session, error) {
+	p.mu.Lock()
+	now := p.now()
+	healthy := p.healthy(now)
+
+	var px *proxy
+	var reservation *rate.Reservation
+	var delay time.Duration
+	if p.opts.Rotation == PerSession {
+		if p.left <= 0 || !healthy[p.next-1] {
+			p.next = p.nextHealthy(healthy)
+			p.left = p.opts.SessionRequests
+		}
+		p.left--
+		px = p.proxies[p.next-1]
+		reservation = px.limiter.ReserveN(now, 1)
+		delay = reservation.DelayFrom(now)
+	} else {
+		soonest := -1
+		for n := 0; n < len(p.proxies); n++ {
+			i := (p.next + n) % len(p.proxies)
+			if !healthy[i] {
+				continue
+			}
+
+			res := p.proxies[i].limiter.ReserveN(now, 1)
+			d := res.DelayFrom(now)
+			if soonest < 0 || d < delay {
+				if reservation != nil {
+					reservation.CancelAt(now)
+				}
+				soonest, delay, reservation = i, d, res
+			} else {
+				res.CancelAt(now)
+			}
+			if d == 0 {
+				break
+			}
+		}
+		px = p.proxies[soonest]
+		p.next = soonest + 1
+	}
+	s := px.session
+	s.inFlight++
+	p.mu.Unlock()
+
+	if delay == 0 {
+		return px, s, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		p.release(s)
+		return nil, nil, ctx.Err()
+	case <-timer.C:
+		return px, s, nil
+	}
+}
+
+// healthy returns whether each proxy is out of quarantine at now, releasing those whose cool-down is over.
+// p.mu must be held.
+func (p *Pool) healthy(now time.Time) []bool {
+	healthy := make([]bool, len(p.proxies))
+	for i, px := range p.proxies {
+		if !px.until.IsZero() && !now.Before(px.until) {
+			px.until = time.Time{}
+			px.stats.Quarantined = false
+		}
+		healthy[i] = px.until.IsZero()
+	}
+
+	return healthy
+}
+
+// nextHealthy returns 1 + the index of the next healthy proxy after proxies[p.next-1]. p.mu must be held.
+func (p *Pool) nextHealthy(healthy []bool) int {
+	for n := 0; n < len(p.proxies); n++ {
+		if i := (p.next + n) % len(p.proxies); healthy[i] {
+			return i + 1
+		}
+	}
+
+	// unreachable, since the last healthy proxy is never quarantined
+	return p.next
+}
+
+// observe records the outcome of a request through px and its session s, quarantining px if too many recent
+// ones were blocked or failed. Requests through a session that px has since moved on from count only toward its stats.
+func (p *Pool) observe(px *proxy, s *session, resp *http.Response, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	px.stats.Requests++
+	var blocked bool
+	switch {
+	case err != nil:
+		px.stats.Errors++
+		// requests given up on by their callers say nothing about the proxy
+		blocked = !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		px.stats.Throttled++
+		blocked = true
+	case resp.StatusCode == http.StatusForbidden:
+		px.stats.Forbidden++
+		blocked = true
+	}
+	if s != px.session {
+		return
+	}
+
+	px.recent = append(px.recent, blocked)
+	if len(px.recent) > p.opts.Window {
+		px.recent = px.recent[1:]
+	}
+	if len(px.recent) < p.opts.Window || !px.until.IsZero() {
+		return
+	}
+
+	var numBlocked int
+	for _, b := range px.recent {
+		if b {
+			numBlocked++
+		}
+	}
+	if float64(numBlocked) < p.opts.MaxBlockRate*float64(len(px.recent)) {
+		return
+	}
+
+	if px.template != "" {
+		if err := p.connect(px, ""); err == nil {
+			px.stats.Renewals++
+			return
+		}
+	}
+
+	now := p.now()
+	var others int
+	for i, healthy := range p.healthy(now) {
+		if healthy && p.proxies[i] != px {
+			others++
+		}
+	}
+	if others == 0 {
+		return
+	}
+
+	px.until = now.Add(p.opts.CoolDown)
+	px.recent = px.recent[:0]
+	px.stats.Quarantines++
+	px.stats.Quarantined = true
+}
+
+// Stats returns a summary of every proxy of p.
+func (p *Pool) Stats() []Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.healthy(p.now())
+	stats := make([]Stats, len(p.proxies))
+	for i, px := range p.proxies {
+		stats[i] = px.stats
+	}
+
+	return stats
+}
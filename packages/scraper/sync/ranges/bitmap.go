@@ -0,0 +1,701 @@
+package ranges
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Bitmap is a compressed set of IDs in the style of a roaring bitmap. IDs are grouped by their high 48 bits,
+// and the low 16 bits of each group are kept in whichever container is smallest: a sorted array for sparse
+// groups, a list of runs for clustered groups, or a 65536-bit bitmap for dense ones.
+//
+// The zero Bitmap is empty and ready to use. IDs must be non-negative. A Bitmap is not safe for concurrent use.
+type Bitmap struct {
+	keys       []uint64
+	containers []*container
+}
+
+var _ encoding.BinaryMarshaler = &Bitmap{}
+var _ encoding.BinaryUnmarshaler = &Bitmap{}
+var _ encoding.TextMarshaler = &Bitmap{}
+var _ encoding.TextUnmarshaler = &Bitmap{}
+
+var ErrInvalidBitmap = errors.New("invalid bitmap")
+
+type containerKind byte
+
+const (
+	arrayContainer containerKind = iota + 1
+	runContainer
+	bitsetContainer
+)
+
+const (
+	// arrayMax is the cardinality above which an array container is larger than a bitset.
+	arrayMax    = 4096
+	bitsetWords = 1 << 16 / 64
+)
+
+// run is an inclusive run of low bits.
+type run struct {
+	start, last uint16
+}
+
+type container struct {
+	kind   containerKind
+	array  []uint16
+	runs   []run
+	bitset []uint64
+}
+
+func split(id int64) (key uint64, low uint16) {
+	return uint64(id) >> 16, uint16(id)
+}
+
+// Bitmap returns a Bitmap holding the IDs in r.
+func (r Ranges) Bitmap() *Bitmap {
+	var b Bitmap
+	b.AddRanges(r)
+	return &b
+}
+
+// Ranges returns the IDs in b as normalized Ranges.
+func (b *Bitmap) Ranges() Ranges {
+	var result Ranges
+	for i, c := range b.containers {
+		base := int64(b.keys[i]) << 16
+		c.eachRun(func(rn run) {
+			rng := Range{startInclusive: base + int64(rn.start), endExclusive: base + int64(rn.last) + 1}
+			if n := len(result); n > 0 && result[n-1].endExclusive == rng.startInclusive {
+				result[n-1].endExclusive = rng.endExclusive
+				return
+			}
+			result = append(result, rng)
+		})
+	}
+
+	return result
+}
+
+// Len returns the number of IDs in b.
+func (b *Bitmap) Len() int64 {
+	var n int64
+	for _, c := range b.containers {
+		n += int64(c.cardinality())
+	}
+
+	return n
+}
+
+// Contains reports whether id is in b.
+func (b *Bitmap) Contains(id int64) bool {
+	if id < 0 {
+		return false
+	}
+
+	key, low := split(id)
+	i, ok := b.find(key)
+	return ok && b.containers[i].contains(low)
+}
+
+// Add inserts id into b.
+func (b *Bitmap) Add(id int64) {
+	key, low := split(id)
+	b.getOrCreate(key).addRun(run{low, low})
+}
+
+// Remove deletes id from b.
+func (b *Bitmap) Remove(id int64) {
+	if id < 0 {
+		return
+	}
+
+	key, low := split(id)
+	if i, ok := b.find(key); ok {
+		b.containers[i].remove(low)
+		b.dropIfEmpty(i)
+	}
+}
+
+// AddRange inserts every ID in r into b.
+func (b *Bitmap) AddRange(r Range) {
+	if r.Len() == 0 {
+		return
+	}
+
+	if r.Step() > 1 {
+		r.Each(func(id int64) bool {
+			b.Add(id)
+			return true
+		})
+		return
+	}
+
+	for id := r.startInclusive; id < r.endExclusive; {
+		key, low := split(id)
+		last := uint16(0xffff)
+		if next := int64(key+1) << 16; next > r.endExclusive {
+			last = uint16(r.endExclusive - 1)
+		}
+
+		b.getOrCreate(key).addRun(run{low, last})
+		id = int64(key+1) << 16
+	}
+}
+
+// AddRanges inserts every ID in r into b.
+func (b *Bitmap) AddRanges(r Ranges) {
+	for i := range r {
+		b.AddRange(r[i])
+	}
+}
+
+// Optimize converts every container to its smallest representation. Adding single IDs defers this work,
+// so call Optimize after adding many IDs one at a time. MarshalBinary encodes an optimized copy of b.
+func (b *Bitmap) Optimize() {
+	for _, c := range b.containers {
+		c.optimize()
+	}
+}
+
+// Clone returns a copy of b that shares no memory with it.
+func (b *Bitmap) Clone() *Bitmap {
+	clone := Bitmap{
+		keys:       append([]uint64(nil), b.keys...),
+		containers: make([]*container, len(b.containers)),
+	}
+	for i, c := range b.containers {
+		clone.containers[i] = &container{
+			kind:   c.kind,
+			array:  append([]uint16(nil), c.array...),
+			runs:   append([]run(nil), c.runs...),
+			bitset: append([]uint64(nil), c.bitset...),
+		}
+	}
+
+	return &clone
+}
+
+// Or adds every ID in other to b.
+func (b *Bitmap) Or(other *Bitmap) {
+	for i, key := range other.keys {
+		b.getOrCreate(key).combine(other.containers[i], func(x, y uint64) uint64 { return x | y })
+	}
+}
+
+// And removes every ID from b that isn't in other.
+func (b *Bitmap) And(other *Bitmap) {
+	for i := 0; i < len(b.keys); i++ {
+		j, ok := other.find(b.keys[i])
+		if !ok {
+			b.containers[i] = &container{kind: arrayContainer}
+		} else {
+			b.containers[i].combine(other.containers[j], func(x, y uint64) uint64 { return x & y })
+		}
+
+		if b.dropIfEmpty(i) {
+			i--
+		}
+	}
+}
+
+// AndNot removes every ID in other from b.
+func (b *Bitmap) AndNot(other *Bitmap) {
+	for i := 0; i < len(b.keys); i++ {
+		if j, ok := other.find(b.keys[i]); ok {
+			b.containers[i].combine(other.containers[j], func(x, y uint64) uint64 { return x &^ y })
+			if b.dropIfEmpty(i) {
+				i--
+			}
+		}
+	}
+}
+
+func (b *Bitmap) find(key uint64) (int, bool) {
+	i := sort.Search(len(b.keys), func(i int) bool { return b.keys[i] >= key })
+	return i, i < len(b.keys) && b.keys[i] == key
+}
+
+func (b *Bitmap) getOrCreate(key uint64) *container {
+	i, ok := b.find(key)
+	if ok {
+		return b.containers[i]
+	}
+
+	c := &container{kind: arrayContainer}
+	b.keys = append(b.keys, 0)
+	copy(b.keys[i+1:], b.keys[i:])
+	b.keys[i] = key
+	b.containers = append(b.containers, nil)
+	copy(b.containers[i+1:], b.containers[i:])
+	b.containers[i] = c
+
+	return c
+}
+
+// dropIfEmpty removes the i-th container if it holds no IDs, reporting whether it did.
+func (b *Bitmap) dropIfEmpty(i int) bool {
+	if b.containers[i].cardinality() > 0 {
+		return false
+	}
+
+	b.keys = append(b.keys[:i], b.keys[i+1:]...)
+	b.containers = append(b.containers[:i], b.containers[i+1:]...)
+	return true
+}
+
+func (c *container) cardinality() int {
+	switch c.kind {
+	case runContainer:
+		n := 0
+		for _, rn := range c.runs {
+			n += int(rn.last-rn.start) + 1
+		}
+		return n
+	case bitsetContainer:
+		n := 0
+		for _, w := range c.bitset {
+			n += bits.OnesCount64(w)
+		}
+		return n
+	default:
+		return len(c.array)
+	}
+}
+
+func (c *container) contains(low uint16) bool {
+	switch c.kind {
+	case runContainer:
+		i := sort.Search(len(c.runs), func(i int) bool { return c.runs[i].last >= low })
+		return i < len(c.runs) && c.runs[i].start <= low
+	case bitsetContainer:
+		return c.bitset[low/64]&(1<<(low%64)) != 0
+	default:
+		i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+		return i < len(c.array) && c.array[i] == low
+	}
+}
+
+// eachRun calls fn for each maximal run of low bits in the container, in ascending order.
+func (c *container) eachRun(fn func(run)) {
+	switch c.kind {
+	case runContainer:
+		for _, rn := range c.runs {
+			fn(rn)
+		}
+	case bitsetContainer:
+		inRun, start := false, 0
+		for w, word := range c.bitset {
+			// skip words that can't start or end a run
+			if !inRun && word == 0 || inRun && word == ^uint64(0) {
+				continue
+			}
+
+			for v := w * 64; v < (w+1)*64; v++ {
+				set := word&(1<<(v%64)) != 0
+				if set && !inRun {
+					inRun, start = true, v
+				} else if !set && inRun {
+					inRun = false
+					fn(run{uint16(start), uint16(v - 1)})
+				}
+			}
+		}
+		if inRun {
+			fn(run{uint16(start), 0xffff})
+		}
+	default:
+		for i := 0; i < len(c.array); {
+			j := i
+			for j+1 < len(c.array) && c.array[j+1] == c.array[j]+1 {
+				j++
+			}
+			fn(run{c.array[i], c.array[j]})
+			i = j + 1
+		}
+	}
+}
+
+// addRun inserts every low bit in rn, then picks the smallest representation.
+func (c *container) addRun(rn run) {
+	// single additions to arrays and bitsets skip optimizing, which would cost more than the addition itself
+	if c.kind == arrayContainer && rn.start == rn.last {
+		i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= rn.start })
+		if i < len(c.array) && c.array[i] == rn.start {
+			return
+		}
+		if len(c.array) < arrayMax {
+			c.array = append(c.array, 0)
+			copy(c.array[i+1:], c.array[i:])
+			c.array[i] = rn.start
+			return
+		}
+
+		c.toBitset()
+	}
+
+	if c.kind == bitsetContainer {
+		for v := int(rn.start); v <= int(rn.last); v++ {
+			c.bitset[v/64] |= 1 << (v % 64)
+		}
+		if rn.start != rn.last {
+			c.optimize()
+		}
+		return
+	}
+
+	var runs []run
+	c.eachRun(func(existing run) { runs = append(runs, existing) })
+	runs = append(runs, rn)
+	sort.Slice(runs, func(i, j int) bool { return runs[i].start < runs[j].start })
+
+	merged := runs[:1]
+	for _, next := range runs[1:] {
+		prev := &merged[len(merged)-1]
+		if int(next.start) <= int(prev.last)+1 {
+			if next.last > prev.last {
+				prev.last = next.last
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+
+	*c = container{kind: runContainer, runs: merged}
+	c.optimize()
+}
+
+// remove deletes low from the container in place. Only bitsets that thin out enough to be smaller as arrays,
+// and runs that split, are optimized.
+func (c *container) remove(low uint16) {
+	switch c.kind {
+	case runContainer:
+		i := sort.Search(len(c.runs), func(i int) bool { return c.runs[i].last >= low })
+		if i == len(c.runs) || c.runs[i].start > low {
+			return
+		}
+
+		switch rn := &c.runs[i]; {
+		case rn.start == rn.last:
+			c.runs = append(c.runs[:i], c.runs[i+1:]...)
+		case low == rn.start:
+			rn.start++
+		case low == rn.last:
+			rn.last--
+		default:
+			tail := run{low + 1, rn.last}
+			rn.last = low - 1
+			c.runs = append(c.runs, run{})
+			copy(c.runs[i+2:], c.runs[i+1:])
+			c.runs[i+1] = tail
+			c.optimize()
+		}
+	case bitsetContainer:
+		c.bitset[low/64] &^= 1 << (low % 64)
+		if c.cardinality() <= arrayMax {
+			c.optimize()
+		}
+	default:
+		i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+		if i < len(c.array) && c.array[i] == low {
+			c.array = append(c.array[:i], c.array[i+1:]...)
+		}
+	}
+}
+
+// toBitset converts the container into a bitset container.
+func (c *container) toBitset() {
+	if c.kind == bitsetContainer {
+		return
+	}
+
+	bitset := make([]uint64, bitsetWords)
+	c.eachRun(func(rn run) {
+		for v := int(rn.start); v <= int(rn.last); v++ {
+			bitset[v/64] |= 1 << (v % 64)
+		}
+	})
+	*c = container{kind: bitsetContainer, bitset: bitset}
+}
+
+// combine replaces the container with op applied word by word to it and other.
+func (c *container) combine(other *container, op func(x, y uint64) uint64) {
+	c.toBitset()
+	o := other
+	if o.kind != bitsetContainer {
+		o = &container{kind: o.kind, array: o.array, runs: o.runs}
+		o.toBitset()
+	}
+
+	for i := range c.bitset {
+		c.bitset[i] = op(c.bitset[i], o.bitset[i])
+	}
+	c.optimize()
+}
+
+// optimize converts the container to whichever representation takes the fewest bytes.
+func (c *container) optimize() {
+	var runs []run
+	c.eachRun(func(rn run) { runs = append(runs, rn) })
+	n := c.cardinality()
+
+	arraySize, runSize, bitsetSize := 2*n, 4*len(runs), 8*bitsetWords
+	switch {
+	case runSize < arraySize && runSize < bitsetSize:
+		if c.kind != runContainer {
+			*c = container{kind: runContainer, runs: runs}
+		}
+	case arraySize <= bitsetSize:
+		if c.kind != arrayContainer {
+			array := make([]uint16, 0, n)
+			for _, rn := range runs {
+				for v := int(rn.start); v <= int(rn.last); v++ {
+					array = append(array, uint16(v))
+				}
+			}
+			*c = container{kind: arrayContainer, array: array}
+		}
+	default:
+		c.toBitset()
+	}
+}
+
+// bitmapMagic starts every serialized Bitmap, followed by a format version.
+const bitmapMagic = "RBM\x01"
+
+// maxKey is the key of the largest ID.
+const maxKey = math.MaxInt64 >> 16
+
+// MarshalBinary encodes b as the magic bytes "RBM\x01", followed by the number of containers as a uvarint,
+// followed by each container: the difference between its key and the previous container's key as a uvarint,
+// a kind byte, then for arrays a uvarint count and little-endian uint16 values, for runs a uvarint count and
+// little-endian uint16 start/last pairs, and for bitsets 1024 little-endian uint64 words.
+// Each container is encoded in its smallest representation, without changing b.
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	buf := []byte(bitmapMagic)
+	buf = appendUvarint(buf, uint64(len(b.keys)))
+
+	var prev uint64
+	for i, c := range b.containers {
+		// optimize replaces the slices of a container rather than writing to them, so a shallow copy will do
+		optimized := *c
+		optimized.optimize()
+		c = &optimized
+
+		buf = appendUvarint(buf, b.keys[i]-prev)
+		prev = b.keys[i]
+
+		buf = append(buf, byte(c.kind))
+		switch c.kind {
+		case arrayContainer:
+			buf = appendUvarint(buf, uint64(len(c.array)))
+			for _, v := range c.array {
+				buf = appendUint16(buf, v)
+			}
+		case runContainer:
+			buf = appendUvarint(buf, uint64(len(c.runs)))
+			for _, rn := range c.runs {
+				buf = appendUint16(buf, rn.start)
+				buf = appendUint16(buf, rn.last)
+			}
+		case bitsetContainer:
+			for _, w := range c.bitset {
+				buf = appendUint64(buf, w)
+			}
+		}
+	}
+
+	return buf, nil
+}
+
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	if len(data) < len(bitmapMagic) || string(data[:len(bitmapMagic)]) != bitmapMagic {
+		return fmt.Errorf("%w: bad header", ErrInvalidBitmap)
+	}
+	d := bitmapDecoder{data: data[len(bitmapMagic):]}
+
+	n := d.uvarint()
+	decoded := Bitmap{}
+	var key uint64
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		delta := d.uvarint()
+		if i > 0 && delta == 0 {
+			return fmt.Errorf("%w: keys out of order", ErrInvalidBitmap)
+		}
+		// no wrapping around to a smaller key, nor past the key of the largest ID
+		if delta > maxKey-key {
+			return fmt.Errorf("%w: key out of range", ErrInvalidBitmap)
+		}
+		key += delta
+
+		c := &container{kind: containerKind(d.byte())}
+		switch c.kind {
+		case arrayContainer:
+			count := d.uvarint()
+			for j := uint64(0); j < count && d.err == nil; j++ {
+				c.array = append(c.array, d.uint16())
+			}
+		case runContainer:
+			count := d.uvarint()
+			for j := uint64(0); j < count && d.err == nil; j++ {
+				c.runs = append(c.runs, run{d.uint16(), d.uint16()})
+			}
+		case bitsetContainer:
+			c.bitset = make([]uint64, bitsetWords)
+			for j := range c.bitset {
+				c.bitset[j] = d.uint64()
+			}
+		default:
+			return fmt.Errorf("%w: unknown container kind %d", ErrInvalidBitmap, c.kind)
+		}
+
+		if d.err == nil {
+			if err := c.validate(); err != nil {
+				return err
+			}
+		}
+
+		decoded.keys = append(decoded.keys, key)
+		decoded.containers = append(decoded.containers, c)
+	}
+
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) > 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidBitmap, len(d.data))
+	}
+
+	*b = decoded
+	return nil
+}
+
+// validate checks that a decoded container holds IDs, in ascending order without duplicates.
+func (c *container) validate() error {
+	if c.cardinality() == 0 {
+		return fmt.Errorf("%w: empty container", ErrInvalidBitmap)
+	}
+
+	switch c.kind {
+	case arrayContainer:
+		for i := 1; i < len(c.array); i++ {
+			if c.array[i] <= c.array[i-1] {
+				return fmt.Errorf("%w: array values out of order", ErrInvalidBitmap)
+			}
+		}
+	case runContainer:
+		for i, rn := range c.runs {
+			if rn.last < rn.start {
+				return fmt.Errorf("%w: run ends before it starts", ErrInvalidBitmap)
+			}
+			// runs must be maximal, so they can't touch either
+			if i > 0 && int(rn.start) <= int(c.runs[i-1].last)+1 {
+				return fmt.Errorf("%w: runs out of order or overlapping", ErrInvalidBitmap)
+			}
+		}
+	}
+
+	return nil
+}
+
+// MarshalText encodes the binary form of b in base64, e.g. for embedding in JSON.
+func (b *Bitmap) MarshalText() ([]byte, error) {
+	buf, err := b.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	text := make([]byte, base64.StdEncoding.EncodedLen(len(buf)))
+	base64.StdEncoding.Encode(text, buf)
+	return text, nil
+}
+
+func (b *Bitmap) UnmarshalText(text []byte) error {
+	buf := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(buf, text)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidBitmap, err.Error())
+	}
+
+	return b.UnmarshalBinary(buf[:n])
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	var tmp [2]byte
+	binary.LittleEndian.PutUint16(tmp[:], v)
+	return append(buf, tmp[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	return append(buf, tmp[:]...)
+}
+
+type bitmapDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *bitmapDecoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidBitmap)
+	}
+	d.data = nil
+}
+
+func (d *bitmapDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *bitmapDecoder) byte() byte {
+	if len(d.data) < 1 {
+		d.fail()
+		return 0
+	}
+
+	v := d.data[0]
+	d.data = d.data[1:]
+	return v
+}
+
+func (d *bitmapDecoder) uint16() uint16 {
+	if len(d.data) < 2 {
+		d.fail()
+		return 0
+	}
+
+	v := binary.LittleEndian.Uint16(d.data)
+	d.data = d.data[2:]
+	return v
+}
+
+func (d *bitmapDecoder) uint64() uint64 {
+	if len(d.data) < 8 {
+		d.fail()
+		return 0
+	}
+
+	v := binary.LittleEndian.Uint64(d.data)
+	d.data = d.data[8:]
+	return v
+}
@@ -0,0 +1,226 @@
+package ranges
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitmap(t *testing.T) {
+	t.Run("ranges", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(65_530, 200_000), newRangeUnsafe(9_999_997_501, 10_000_000_000)}
+		b := rngs.Bitmap()
+
+		assert.Equal(t, rngs, b.Ranges())
+		assert.Equal(t, rngs.Len(), b.Len())
+		assert.True(t, b.Contains(65_536))
+		assert.False(t, b.Contains(11))
+		assert.False(t, b.Contains(-1))
+
+		b.Remove(100_000)
+		assert.False(t, b.Contains(100_000))
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(65_530, 99_999), newRangeUnsafe(100_001, 200_000), newRangeUnsafe(9_999_997_501, 10_000_000_000)}, b.Ranges())
+	})
+
+	t.Run("stepped", func(t *testing.T) {
+		b := Ranges{newSteppedRangeUnsafe(1, 100_000, 3)}.Bitmap()
+		assert.Equal(t, int64(33_334), b.Len())
+		assert.True(t, b.Contains(99_997))
+		assert.False(t, b.Contains(99_998))
+	})
+
+	t.Run("set operations against brute force", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(1))
+		random := func(n int, max int64) (*Bitmap, map[int64]bool) {
+			var b Bitmap
+			set := make(map[int64]bool)
+			for i := 0; i < n; i++ {
+				id := rnd.Int63n(max)
+				b.Add(id)
+				set[id] = true
+			}
+			// mix in a run so every container kind gets exercised
+			lo := rnd.Int63n(max)
+			b.AddRange(newRangeUnsafe(lo, lo+70_000))
+			for id := lo; id <= lo+70_000; id++ {
+				set[id] = true
+			}
+			return &b, set
+		}
+
+		a, sa := random(20_000, 300_000)
+		b, sb := random(500, 300_000)
+
+		check := func(t *testing.T, expected map[int64]bool, actual *Bitmap) {
+			assert.Equal(t, int64(len(expected)), actual.Len())
+			for id := range expected {
+				if !actual.Contains(id) {
+					t.Fatalf("missing %d", id)
+				}
+			}
+		}
+
+		union, intersection, difference := make(map[int64]bool), make(map[int64]bool), make(map[int64]bool)
+		for id := range sa {
+			union[id] = true
+			if sb[id] {
+				intersection[id] = true
+			} else {
+				difference[id] = true
+			}
+		}
+		for id := range sb {
+			union[id] = true
+		}
+
+		or := a.Clone()
+		or.Or(b)
+		check(t, union, or)
+
+		and := a.Clone()
+		and.And(b)
+		check(t, intersection, and)
+
+		andNot := a.Clone()
+		andNot.AndNot(b)
+		check(t, difference, andNot)
+
+		// the clones didn't disturb the original
+		check(t, sa, a)
+
+		removed := a.Clone()
+		remaining := make(map[int64]bool, len(sa))
+		for id := range sa {
+			remaining[id] = true
+		}
+		for i := 0; i < 30_000; i++ {
+			id := rnd.Int63n(300_000)
+			removed.Remove(id)
+			delete(remaining, id)
+		}
+		check(t, remaining, removed)
+	})
+
+	t.Run("remove keeps the container kind", func(t *testing.T) {
+		b := Ranges{newRangeUnsafe(0, 1000)}.Bitmap()
+		b.Remove(500)
+		b.Remove(0)
+		b.Remove(1000)
+		require.Equal(t, runContainer, b.containers[0].kind)
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 499), newRangeUnsafe(501, 999)}, b.Ranges())
+
+		var sparse Bitmap
+		sparse.Add(3)
+		sparse.Add(7)
+		sparse.Remove(3)
+		require.Equal(t, arrayContainer, sparse.containers[0].kind)
+		sparse.Remove(7)
+		assert.Zero(t, sparse.Len())
+		assert.Empty(t, sparse.containers)
+	})
+
+	t.Run("compression", func(t *testing.T) {
+		buf, err := Ranges{newRangeUnsafe(1, 10_000_000_000)}.Bitmap().MarshalBinary()
+		require.NoError(t, err)
+		// ten billion contiguous IDs take up one run per container, not ten billion bits
+		assert.Less(t, len(buf), 160_000*8)
+	})
+
+	t.Run("binary round trip", func(t *testing.T) {
+		var b Bitmap
+		b.AddRanges(Ranges{newRangeUnsafe(1, 10_000_000), newRangeUnsafe(20_000_000_000, 20_000_000_000)})
+		for i := int64(0); i < 40_000; i += 2 {
+			b.Add(50_000_000 + i) // dense, but without runs
+		}
+		for i := int64(0); i < 100; i++ {
+			b.Add(80_000_000 + i*7) // sparse
+		}
+
+		kinds := func(b *Bitmap) []containerKind {
+			var kinds []containerKind
+			for _, c := range b.containers {
+				kinds = append(kinds, c.kind)
+			}
+			return kinds
+		}
+		before := kinds(&b)
+
+		buf, err := b.MarshalBinary()
+		require.NoError(t, err)
+		assert.Equal(t, before, kinds(&b), "marshaling leaves b as it was")
+
+		var decoded Bitmap
+		require.NoError(t, decoded.UnmarshalBinary(buf))
+		assert.Equal(t, b.Ranges(), decoded.Ranges())
+
+		txt, err := b.MarshalText()
+		require.NoError(t, err)
+		var fromText Bitmap
+		require.NoError(t, fromText.UnmarshalText(txt))
+		assert.Equal(t, b.Ranges(), fromText.Ranges())
+
+		assert.ErrorIs(t, decoded.UnmarshalBinary(buf[:len(buf)-1]), ErrInvalidBitmap)
+		assert.ErrorIs(t, decoded.UnmarshalBinary([]byte("nope")), ErrInvalidBitmap)
+	})
+
+	t.Run("rejects unordered contents", func(t *testing.T) {
+		type testCase struct {
+			name      string
+			container []byte
+		}
+
+		for _, tc := range []testCase{
+			{"empty array", []byte{byte(arrayContainer), 0}},
+			{"unsorted array", []byte{byte(arrayContainer), 2, 5, 0, 3, 0}},
+			{"duplicate array values", []byte{byte(arrayContainer), 2, 3, 0, 3, 0}},
+			{"backwards run", []byte{byte(runContainer), 1, 9, 0, 3, 0}},
+			{"unsorted runs", []byte{byte(runContainer), 2, 10, 0, 12, 0, 1, 0, 3, 0}},
+			{"overlapping runs", []byte{byte(runContainer), 2, 1, 0, 5, 0, 4, 0, 8, 0}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				buf := append([]byte(bitmapMagic), 1, 0)
+				buf = append(buf, tc.container...)
+
+				var b Bitmap
+				assert.ErrorIs(t, b.UnmarshalBinary(buf), ErrInvalidBitmap)
+			})
+		}
+	})
+
+	t.Run("rejects keys out of range", func(t *testing.T) {
+		type testCase struct {
+			name   string
+			deltas []uint64
+		}
+
+		for _, tc := range []testCase{
+			{"wrapping key", []uint64{5, math.MaxUint64}},
+			{"negative IDs", []uint64{maxKey + 1}},
+			{"past the largest ID", []uint64{maxKey, 1}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				buf := append([]byte(bitmapMagic), byte(len(tc.deltas)))
+				for _, delta := range tc.deltas {
+					varint := make([]byte, binary.MaxVarintLen64)
+					buf = append(buf, varint[:binary.PutUvarint(varint, delta)]...)
+					buf = append(buf, byte(arrayContainer), 1, 0, 0)
+				}
+
+				var b Bitmap
+				assert.ErrorIs(t, b.UnmarshalBinary(buf), ErrInvalidBitmap)
+			})
+		}
+
+		var b Bitmap
+		b.Add(math.MaxInt64)
+		buf, err := b.MarshalBinary()
+		require.NoError(t, err)
+		var decoded Bitmap
+		require.NoError(t, decoded.UnmarshalBinary(buf), "the largest ID still fits")
+		assert.True(t, decoded.Contains(math.MaxInt64))
+	})
+}
@@ -0,0 +1,370 @@
+package ranges
+
+import (
+	"encoding"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Strategy is a way of choosing which IDs a Traversal visits next.
+type Strategy int
+
+const (
+	// Descending visits the highest IDs first, like Pop.
+	Descending Strategy = iota
+	// Ascending visits the lowest IDs first, like PopFront.
+	Ascending
+	// Interleaved splits the IDs into Order.Shards equally sized regions, as by Ranges.Split, and takes from
+	// each region in turn, highest IDs first within a region.
+	Interleaved
+	// Shuffled visits blocks of IDs in a pseudo-random order determined by Order.Seed.
+	// The block size is the n passed to the first call to Pop.
+	Shuffled
+)
+
+var strategyNames = map[Strategy]string{
+	Descending:  "descending",
+	Ascending:   "ascending",
+	Interleaved: "interleaved",
+	Shuffled:    "shuffled",
+}
+
+func (s Strategy) String() string {
+	if name, ok := strategyNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Strategy(%d)", int(s))
+}
+
+// Order describes how a Traversal visits the IDs of a Ranges. The zero Order is Descending.
+// Its text representation is the strategy name, followed by the shard count or seed where applicable,
+// e.g. "ascending", "interleaved:16" or "shuffled:42".
+type Order struct {
+	Strategy Strategy
+	// Shards is the number of regions visited in turn by an Interleaved Order.
+	Shards int
+	// Seed determines the permutation used by a Shuffled Order.
+	Seed int64
+}
+
+var _ encoding.TextUnmarshaler = &Order{}
+var _ encoding.TextMarshaler = Order{}
+
+func (o *Order) UnmarshalText(text []byte) error {
+	s := string(text)
+	name, param := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		name, param = s[:i], s[i+1:]
+	}
+
+	switch name {
+	case "", strategyNames[Descending]:
+		*o = Order{Strategy: Descending}
+	case strategyNames[Ascending]:
+		*o = Order{Strategy: Ascending}
+	case strategyNames[Interleaved]:
+		shards, err := strconv.Atoi(param)
+		if err != nil || shards <= 0 {
+			return fmt.Errorf("invalid shard count for interleaved order: %q", s)
+		}
+		*o = Order{Strategy: Interleaved, Shards: shards}
+		return nil
+	case strategyNames[Shuffled]:
+		seed, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid seed for shuffled order: %q", s)
+		}
+		*o = Order{Strategy: Shuffled, Seed: seed}
+		return nil
+	default:
+		return fmt.Errorf("unknown order: %q", s)
+	}
+
+	if param != "" {
+		return fmt.Errorf("unexpected parameter for %s order: %q", name, s)
+	}
+
+	return nil
+}
+
+func (o Order) MarshalText() ([]byte, error) {
+	switch o.Strategy {
+	case Descending, Ascending:
+		return []byte(o.Strategy.String()), nil
+	case Interleaved:
+		return []byte(fmt.Sprintf("%s:%d", o.Strategy, o.Shards)), nil
+	case Shuffled:
+		return []byte(fmt.Sprintf("%s:%d", o.Strategy, o.Seed)), nil
+	default:
+		return nil, fmt.Errorf("unknown order strategy: %v", o.Strategy)
+	}
+}
+
+func (o Order) String() string {
+	buf, err := o.MarshalText()
+	if err != nil {
+		return o.Strategy.String()
+	}
+
+	return string(buf)
+}
+
+// Traversal hands out the IDs of a Ranges in chunks, in the sequence given by an Order.
+// Every ID is handed out exactly once, assuming the Ranges doesn't overlap itself.
+type Traversal struct {
+	traverser
+}
+
+type traverser interface {
+	// popInto appends at most n IDs to dst, returning an empty Ranges once every ID has been visited.
+	popInto(dst Ranges, n int) Ranges
+	// len returns the number of IDs left to visit.
+	len() int64
+}
+
+// Traverse returns a Traversal over the IDs of r in the given Order. r itself is not modified.
+// An Interleaved Order with no Shards traverses r as if it had a single shard.
+func (r Ranges) Traverse(o Order) *Traversal {
+	rngs := append(Ranges(nil), r...)
+
+	switch o.Strategy {
+	case Ascending:
+		return &Traversal{(*ascending)(&rngs)}
+	case Interleaved:
+		return &Traversal{newInterleaved(rngs, o.Shards)}
+	case Shuffled:
+		return &Traversal{newShuffled(rngs, o.Seed)}
+	default:
+		return &Traversal{(*descending)(&rngs)}
+	}
+}
+
+// Pop returns the next chunk of at most n IDs, or an empty Ranges once every ID has been visited.
+func (t *Traversal) Pop(n int) Ranges {
+	return t.popInto(nil, n)
+}
+
+// Len returns the number of IDs left to visit.
+func (t *Traversal) Len() int64 {
+	return t.len()
+}
+
+// Chunks returns a Chunker yielding batches of at most n IDs from the rest of t.
+func (t *Traversal) Chunks(n int) *Chunker {
+	return &Chunker{
+		t:   t,
+		n:   n,
+		buf: make([]int64, 0, n),
+	}
+}
+
+type descending Ranges
+
+func (d *descending) popInto(dst Ranges, n int) Ranges {
+	return (*Ranges)(d).popInto(dst, n)
+}
+
+func (d *descending) len() int64 {
+	return Ranges(*d).Len()
+}
+
+type ascending Ranges
+
+func (a *ascending) popInto(dst Ranges, n int) Ranges {
+	return (*Ranges)(a).popFrontInto(dst, n)
+}
+
+func (a *ascending) len() int64 {
+	return Ranges(*a).Len()
+}
+
+type interleaved struct {
+	shards []Ranges
+	next   int
+}
+
+func newInterleaved(rngs Ranges, shards int) *interleaved {
+	if shards <= 0 {
+		shards = 1
+	}
+
+	return &interleaved{shards: rngs.Split(shards)}
+}
+
+func (t *interleaved) popInto(dst Ranges, n int) Ranges {
+	for range t.shards {
+		shard := &t.shards[t.next]
+		t.next = (t.next + 1) % len(t.shards)
+
+		if shard.Len() > 0 {
+			return shard.popInto(dst, n)
+		}
+	}
+
+	return dst
+}
+
+func (t *interleaved) len() int64 {
+	var n int64
+	for _, shard := range t.shards {
+		n += shard.Len()
+	}
+
+	return n
+}
+
+type shuffled struct {
+	rngs    Ranges
+	offsets []int64
+	total   int64
+	seed    int64
+
+	// the block size and permutation are fixed on the first pop
+	block   int64
+	perm    permutation
+	cursor  uint64
+	current Ranges
+	visited int64
+}
+
+func newShuffled(rngs Ranges, seed int64) *shuffled {
+	return &shuffled{
+		rngs:    rngs,
+		offsets: rngs.offsets(),
+		total:   rngs.Len(),
+		seed:    seed,
+	}
+}
+
+func (t *shuffled) popInto(dst Ranges, n int) Ranges {
+	if t.block == 0 {
+		if n <= 0 || t.total == 0 {
+			return dst
+		}
+
+		t.block = int64(n)
+		t.perm = newPermutation(uint64((t.total+t.block-1)/t.block), t.seed)
+	}
+
+	if t.current.Len() == 0 {
+		if t.cursor >= t.perm.n {
+			return dst
+		}
+
+		b := int64(t.perm.at(t.cursor))
+		t.cursor++
+
+		hi := (b + 1) * t.block
+		if hi > t.total {
+			hi = t.total
+		}
+		t.current = t.rngs.slice(t.offsets, b*t.block, hi)
+	}
+
+	before := len(dst)
+	dst = t.current.popInto(dst, n)
+	t.visited += dst[before:].Len()
+
+	return dst
+}
+
+func (t *shuffled) len() int64 {
+	return t.total - t.visited
+}
+
+// offsets returns the ordinal of the first ID of each Range in r, counting IDs Range by Range.
+func (r Ranges) offsets() []int64 {
+	offsets := make([]int64, len(r))
+	var n int64
+	for i := range r {
+		offsets[i] = n
+		n += r[i].Len()
+	}
+
+	return offsets
+}
+
+// slice returns the IDs of r whose ordinals are in [lo, hi), given the offsets of r.
+func (r Ranges) slice(offsets []int64, lo, hi int64) Ranges {
+	var result Ranges
+	// find the last Range starting at or before lo
+	i := sort.Search(len(offsets), func(i int) bool { return offsets[i] > lo }) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	for ; i < len(r) && lo < hi; i++ {
+		skip := lo - offsets[i]
+		count := r[i].Len() - skip
+		if count > hi-lo {
+			count = hi - lo
+		}
+		if count <= 0 {
+			continue
+		}
+
+		step := r[i].Step()
+		first := r[i].startInclusive + skip*step
+		result = append(result, newProgression(first, first+(count-1)*step, step))
+		lo += count
+	}
+
+	return result
+}
+
+// permutation is a seeded pseudo-random bijection on [0, n), built from a small Feistel network
+// with cycle walking, so that it can be evaluated at any index without materializing it.
+type permutation struct {
+	n        uint64
+	halfBits uint
+	keys     [4]uint64
+}
+
+func newPermutation(n uint64, seed int64) permutation {
+	width := uint(bits.Len64(n - 1))
+	if width < 2 {
+		width = 2
+	}
+	if width%2 == 1 {
+		width++
+	}
+
+	p := permutation{n: n, halfBits: width / 2}
+	state := uint64(seed)
+	for i := range p.keys {
+		state += 0x9e3779b97f4a7c15
+		p.keys[i] = mix64(state)
+	}
+
+	return p
+}
+
+func (p permutation) at(i uint64) uint64 {
+	mask := uint64(1)<<p.halfBits - 1
+	for {
+		l, r := i>>p.halfBits, i&mask
+		for _, key := range p.keys {
+			l, r = r, l^(mix64(r^key)&mask)
+		}
+
+		// the network permutes [0, 2^width), so walk the cycle until we land back in [0, n)
+		i = l<<p.halfBits | r
+		if i < p.n {
+			return i
+		}
+	}
+}
+
+// mix64 is the splitmix64 finalizer.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,88 @@
+package ranges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		type testCase struct {
+			text     string
+			expected Order
+		}
+
+		cases := []testCase{
+			{text: "", expected: Order{Strategy: Descending}},
+			{text: "descending", expected: Order{Strategy: Descending}},
+			{text: "ascending", expected: Order{Strategy: Ascending}},
+			{text: "interleaved:16", expected: Order{Strategy: Interleaved, Shards: 16}},
+			{text: "shuffled:-42", expected: Order{Strategy: Shuffled, Seed: -42}},
+		}
+
+		for _, c := range cases {
+			var o Order
+			require.NoError(t, o.UnmarshalText([]byte(c.text)), c.text)
+			assert.Equal(t, c.expected, o)
+
+			txt, err := o.MarshalText()
+			require.NoError(t, err)
+			var roundTripped Order
+			require.NoError(t, roundTripped.UnmarshalText(txt))
+			assert.Equal(t, o, roundTripped)
+		}
+
+		for _, text := range []string{"sideways", "interleaved", "interleaved:0", "shuffled:x", "ascending:1"} {
+			var o Order
+			assert.Error(t, o.UnmarshalText([]byte(text)), text)
+		}
+	})
+
+	t.Run("ascending", func(t *testing.T) {
+		tr := Ranges{newRangeUnsafe(1, 3), newRangeUnsafe(10, 14)}.Traverse(Order{Strategy: Ascending})
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 3), newRangeUnsafe(10, 10)}, tr.Pop(4))
+		assert.Equal(t, int64(4), tr.Len())
+		assert.Equal(t, Ranges{newRangeUnsafe(11, 14)}, tr.Pop(4))
+		assert.Empty(t, tr.Pop(4))
+	})
+
+	t.Run("interleaved", func(t *testing.T) {
+		tr := Ranges{newRangeUnsafe(1, 300)}.Traverse(Order{Strategy: Interleaved, Shards: 3})
+		assert.Equal(t, Ranges{newRangeUnsafe(51, 100)}, tr.Pop(50))
+		assert.Equal(t, Ranges{newRangeUnsafe(151, 200)}, tr.Pop(50))
+		assert.Equal(t, Ranges{newRangeUnsafe(251, 300)}, tr.Pop(50))
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 50)}, tr.Pop(50))
+		assert.Equal(t, int64(100), tr.Len())
+	})
+
+	t.Run("shuffled", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 1000), newRangeUnsafe(5000, 5999)}
+
+		visit := func(seed int64) (visited []int64) {
+			c := rngs.Traverse(Order{Strategy: Shuffled, Seed: seed}).Chunks(64)
+			for ids := c.Next(); ids != nil; ids = c.Next() {
+				visited = append(visited, ids...)
+			}
+			return
+		}
+
+		visited := visit(42)
+		assert.ElementsMatch(t, rngs.AsIntSlice(), visited)
+		assert.NotEqual(t, rngs.AsIntSlice(), visited)
+		assert.Equal(t, visited, visit(42))
+		assert.NotEqual(t, visited, visit(43))
+	})
+
+	t.Run("permutation", func(t *testing.T) {
+		for _, n := range []uint64{1, 2, 3, 17, 1000} {
+			p := newPermutation(n, 7)
+			seen := make(map[uint64]bool)
+			for i := uint64(0); i < n; i++ {
+				seen[p.at(i)] = true
+			}
+			assert.Len(t, seen, int(n))
+		}
+	})
+}
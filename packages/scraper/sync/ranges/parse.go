@@ -0,0 +1,205 @@
+package ranges
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Frontier is the highest asset ID assumed to exist. Open-ended Ranges such as 9000000000- extend up to it.
+var Frontier int64 = 10_000_000_000
+
+// SyntaxError describes text that couldn't be parsed, pointing at the offending token. It's also used by
+// parsers of other expressions, such as asset predicates, which wrap their own sentinel in it.
+type SyntaxError struct {
+	// Err is the sentinel error for what the text was parsed as. It defaults to ErrInvalidRange.
+	Err error
+	// Text is the full text being parsed.
+	Text string
+	// Offset is the byte offset of the offending token in Text.
+	Offset int
+	// Token is the offending token, or empty at the end of Text.
+	Token string
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	token := "end of input"
+	if e.Token != "" {
+		token = strconv.Quote(e.Token)
+	}
+
+	return fmt.Sprintf("%s %q: %s at offset %d (%s)", e.Unwrap(), e.Text, e.Msg, e.Offset, token)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	if e.Err == nil {
+		return ErrInvalidRange
+	}
+
+	return e.Err
+}
+
+// suffixes are the multipliers accepted at the end of a number, e.g. 2.5B.
+var suffixes = map[byte]int64{
+	'k': 1_000, 'K': 1_000,
+	'm': 1_000_000, 'M': 1_000_000,
+	'b': 1_000_000_000, 'B': 1_000_000_000,
+	't': 1_000_000_000_000, 'T': 1_000_000_000_000,
+}
+
+type parser struct {
+	text string
+	pos  int
+}
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.text)
+}
+
+func (p *parser) peek() byte {
+	if p.atEnd() {
+		return 0
+	}
+
+	return p.text[p.pos]
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.atEnd() || p.peek() != c {
+		return false
+	}
+
+	p.pos++
+	return true
+}
+
+// token returns the token starting at offset: a run of number characters, or a single other character.
+func (p *parser) token(offset int) string {
+	end := offset
+	for end < len(p.text) && isNumberChar(p.text[end]) {
+		end++
+	}
+	if end == offset && end < len(p.text) {
+		end++
+	}
+
+	return p.text[offset:end]
+}
+
+func (p *parser) errorAt(offset int, format string, args ...interface{}) error {
+	return &SyntaxError{
+		Text:   p.text,
+		Offset: offset,
+		Token:  p.token(offset),
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *parser) expectEnd() error {
+	if !p.atEnd() {
+		return p.errorAt(p.pos, "unexpected token")
+	}
+
+	return nil
+}
+
+// parseRange parses bound [- [bound]] [/step], or nothing at all, which yields the empty Range.
+func (p *parser) parseRange() (Range, error) {
+	if p.atEnd() || p.peek() == ',' {
+		return Range{}, nil
+	}
+
+	startOffset := p.pos
+	start, err := p.parseNumber()
+	if err != nil {
+		return Range{}, err
+	}
+
+	end := start
+	endOffset := startOffset
+	if p.consume('-') {
+		endOffset = p.pos
+		if p.atEnd() || p.peek() == ',' || p.peek() == '/' {
+			end = Frontier
+		} else if end, err = p.parseNumber(); err != nil {
+			return Range{}, err
+		}
+	}
+
+	if end < start {
+		if endOffset == p.pos {
+			return Range{}, p.errorAt(startOffset, "lower bound %d is beyond the frontier %d", start, Frontier)
+		}
+		return Range{}, p.errorAt(endOffset, "upper bound %d is below lower bound %d", end, start)
+	}
+	if end == math.MaxInt64 {
+		return Range{}, p.errorAt(endOffset, "upper bound is too large")
+	}
+
+	step := int64(1)
+	if p.consume('/') {
+		stepOffset := p.pos
+		if step, err = p.parseNumber(); err != nil {
+			return Range{}, err
+		}
+		if step == 0 {
+			return Range{}, p.errorAt(stepOffset, "step must be positive")
+		}
+	}
+
+	return newProgression(start, start+(end-start)/step*step, step), nil
+}
+
+// parseNumber parses a non-negative integer, optionally written as a decimal with a suffix, e.g. 2.5B.
+func (p *parser) parseNumber() (int64, error) {
+	offset := p.pos
+	tok := p.token(offset)
+	if tok == "" || !isNumberChar(tok[0]) {
+		return 0, p.errorAt(offset, "expected a number")
+	}
+	p.pos += len(tok)
+
+	digits, multiplier := tok, int64(1)
+	if m, ok := suffixes[tok[len(tok)-1]]; ok {
+		digits, multiplier = tok[:len(tok)-1], m
+	}
+
+	whole, frac := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, frac = digits[:i], digits[i+1:]
+	}
+	if whole == "" || !isDigits(whole) || !isDigits(frac) || strings.Contains(digits, ".") && frac == "" {
+		return 0, p.errorAt(offset, "invalid number")
+	}
+
+	value, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return 0, p.errorAt(offset, "invalid number")
+	}
+	value.Mul(value, new(big.Rat).SetInt64(multiplier))
+	if !value.IsInt() {
+		return 0, p.errorAt(offset, "%s is not a whole number", tok)
+	}
+	if !value.Num().IsInt64() {
+		return 0, p.errorAt(offset, "%s is out of range", tok)
+	}
+
+	return value.Num().Int64(), nil
+}
+
+func isNumberChar(c byte) bool {
+	return '0' <= c && c <= '9' || c == '.' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,199 @@
+package ranges
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSteppedRangeUnsafe(start, end, step int64) Range {
+	return newProgression(start, start+(end-start)/step*step, step)
+}
+
+func TestParse(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		type testCase struct {
+			text      string
+			expected  Ranges
+			marshaled string
+		}
+
+		cases := []testCase{
+			{
+				text:      "1-10,11-20",
+				expected:  Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(11, 20)},
+				marshaled: "1-10,11-20",
+			},
+			{
+				text:      "42",
+				expected:  Ranges{newRangeUnsafe(42, 42)},
+				marshaled: "42-42",
+			},
+			{
+				text:      "10M-2.5B",
+				expected:  Ranges{newRangeUnsafe(10_000_000, 2_500_000_000)},
+				marshaled: "10000000-2500000000",
+			},
+			{
+				text:      "1.5k-2K,9.999999999B-",
+				expected:  Ranges{newRangeUnsafe(1500, 2000), newRangeUnsafe(9_999_999_999, 10_000_000_000)},
+				marshaled: "1500-2000,9999999999-10000000000",
+			},
+			{
+				text:      "1-1B,!500M-600M",
+				expected:  Ranges{newRangeUnsafe(1, 499_999_999), newRangeUnsafe(600_000_001, 1_000_000_000)},
+				marshaled: "1-499999999,600000001-1000000000",
+			},
+			{
+				text:      "1-1B/1000",
+				expected:  Ranges{newSteppedRangeUnsafe(1, 1_000_000_000, 1000)},
+				marshaled: "1-999999001/1000",
+			},
+			{
+				text:      "1-100/10,!41-60",
+				expected:  Ranges{newSteppedRangeUnsafe(1, 31, 10), newSteppedRangeUnsafe(61, 91, 10)},
+				marshaled: "1-31/10,61-91/10",
+			},
+			{
+				text:      "9B-/1B",
+				expected:  Ranges{newSteppedRangeUnsafe(9_000_000_000, 10_000_000_000, 1_000_000_000)},
+				marshaled: "9000000000-10000000000/1000000000",
+			},
+		}
+
+		for _, c := range cases {
+			var r Ranges
+			require.NoError(t, r.UnmarshalText([]byte(c.text)), c.text)
+			assert.Equal(t, c.expected, r, c.text)
+
+			txt, err := r.MarshalText()
+			require.NoError(t, err)
+			assert.Equal(t, c.marshaled, string(txt))
+
+			var roundTripped Ranges
+			require.NoError(t, roundTripped.UnmarshalText(txt))
+			assert.Equal(t, r, roundTripped)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		type testCase struct {
+			text   string
+			offset int
+			token  string
+		}
+
+		cases := []testCase{
+			{text: "1-10,x", offset: 5, token: "x"},
+			{text: "1-10,2.5", offset: 5, token: "2.5"},
+			{text: "1-1.2345K", offset: 2, token: "1.2345K"},
+			{text: "10-5", offset: 3, token: "5"},
+			{text: "11B-", offset: 0, token: "11B"},
+			{text: "1-10/0", offset: 5, token: "0"},
+			{text: "1--10", offset: 2, token: "-"},
+			{text: "1-10!", offset: 4, token: "!"},
+			{text: "1-100000000T", offset: 2, token: "100000000T"},
+			{text: "1-10/", offset: 5, token: ""},
+		}
+
+		for _, c := range cases {
+			var r Ranges
+			err := r.UnmarshalText([]byte(c.text))
+			require.Error(t, err, c.text)
+			assert.ErrorIs(t, err, ErrInvalidRange)
+
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr))
+			assert.Equal(t, c.offset, syntaxErr.Offset, c.text)
+			assert.Equal(t, c.token, syntaxErr.Token, c.text)
+		}
+	})
+
+	t.Run("single range", func(t *testing.T) {
+		var r Range
+		require.NoError(t, r.UnmarshalText([]byte("5-25/10")))
+		assert.Equal(t, []int64{5, 15, 25}, r.AsIntSlice())
+		assert.Error(t, r.UnmarshalText([]byte("1-10,11-20")))
+	})
+}
+
+func TestStepped(t *testing.T) {
+	t.Run("pop", func(t *testing.T) {
+		r := newSteppedRangeUnsafe(1, 100, 10)
+		assert.Equal(t, newSteppedRangeUnsafe(71, 91, 10), r.Pop(3))
+		assert.Equal(t, newSteppedRangeUnsafe(1, 61, 10), r)
+		assert.Equal(t, newSteppedRangeUnsafe(1, 11, 10), r.PopFront(2))
+		assert.Equal(t, newSteppedRangeUnsafe(21, 61, 10), r)
+		assert.Equal(t, newRangeUnsafe(61, 61), r.Pop(1))
+		assert.Equal(t, int64(4), r.Len())
+	})
+
+	t.Run("set algebra", func(t *testing.T) {
+		odds := Ranges{newSteppedRangeUnsafe(1, 99, 2)}
+		evens := Ranges{newSteppedRangeUnsafe(2, 100, 2)}
+		all := Ranges{newRangeUnsafe(1, 100)}
+
+		assert.Empty(t, odds.Intersect(evens))
+		assert.Equal(t, odds, all.Subtract(evens))
+		assert.Equal(t, int64(100), odds.Union(evens).Len())
+		assert.ElementsMatch(t, all.AsIntSlice(), odds.Union(evens).AsIntSlice())
+
+		// multiples of 6 are where every other and every third ID meet
+		threes := Ranges{newSteppedRangeUnsafe(3, 99, 3)}
+		assert.Equal(t, Ranges{newSteppedRangeUnsafe(6, 96, 6)}, evens.Intersect(threes))
+		assert.Equal(t, Ranges{newSteppedRangeUnsafe(2, 4, 2), newSteppedRangeUnsafe(8, 92, 6), newSteppedRangeUnsafe(10, 94, 6), newSteppedRangeUnsafe(98, 100, 2)}, evens.Subtract(threes))
+		assert.Equal(t, int64(50-16), evens.Subtract(threes).Len())
+
+		assert.True(t, odds.Contains(51))
+		assert.False(t, odds.Contains(52))
+		assert.True(t, odds.Overlaps(threes))
+	})
+
+	t.Run("set algebra against brute force", func(t *testing.T) {
+		sets := []Ranges{
+			{newSteppedRangeUnsafe(1, 200, 7), newRangeUnsafe(50, 60)},
+			{newSteppedRangeUnsafe(3, 180, 5), newSteppedRangeUnsafe(100, 200, 3)},
+			{newRangeUnsafe(10, 20), newRangeUnsafe(150, 160), newSteppedRangeUnsafe(0, 200, 11)},
+		}
+
+		toSet := func(r Ranges) map[int64]bool {
+			set := make(map[int64]bool)
+			r.Each(func(id int64) bool {
+				set[id] = true
+				return true
+			})
+			return set
+		}
+
+		for _, a := range sets {
+			for _, b := range sets {
+				sa, sb := toSet(a), toSet(b)
+
+				union, intersection, difference := make(map[int64]bool), make(map[int64]bool), make(map[int64]bool)
+				for id := range sa {
+					union[id] = true
+					if sb[id] {
+						intersection[id] = true
+					} else {
+						difference[id] = true
+					}
+				}
+				for id := range sb {
+					union[id] = true
+				}
+
+				for expected, actual := range map[*map[int64]bool]Ranges{
+					&union:        a.Union(b),
+					&intersection: a.Intersect(b),
+					&difference:   a.Subtract(b),
+				} {
+					assert.Equal(t, *expected, toSet(actual))
+					assert.Equal(t, int64(len(*expected)), actual.Len(), "Ranges should be disjoint")
+					assert.True(t, actual.IsNormalized())
+				}
+			}
+		}
+	})
+}
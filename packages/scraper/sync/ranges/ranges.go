@@ -4,14 +4,17 @@ import (
 	"encoding"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 )
 
 // Range is an non-negative integer range whose text representation is an inclusive range, e.g. 1-10.
+// A Range may also step over IDs, e.g. 1-100/10 holds 1, 11, ..., 91; see UnmarshalText for the full syntax.
 // All Range objects have startInclusive < endExclusive by construction, except for the empty Range.
+// Stepped Ranges always have endExclusive one past their last ID, and hold at least two IDs.
 type Range struct {
 	startInclusive, endExclusive int64
+	// step is the distance between consecutive IDs, or 0 for a contiguous Range.
+	step int64
 }
 
 var _ encoding.TextUnmarshaler = &Range{}
@@ -27,6 +30,33 @@ func NewRange(start, end int64) (Range, error) {
 	return Range{startInclusive: start, endExclusive: end + 1}, nil
 }
 
+// NewSteppedRange returns the Range holding start, start+step, start+2*step, ... up to end inclusive.
+func NewSteppedRange(start, end, step int64) (Range, error) {
+	if step <= 0 {
+		return Range{}, ErrInvalidRange
+	}
+
+	rng, err := NewRange(start, end)
+	if err != nil || rng.Len() == 0 {
+		return rng, err
+	}
+
+	return newProgression(start, start+(end-start)/step*step, step), nil
+}
+
+// newProgression returns the canonical Range holding first, first+step, ..., last.
+// last must be reachable from first in steps of step.
+func newProgression(first, last, step int64) Range {
+	if last < first {
+		return Range{}
+	}
+	if step <= 1 || first == last {
+		return Range{startInclusive: first, endExclusive: last + 1}
+	}
+
+	return Range{startInclusive: first, endExclusive: last + 1, step: step}
+}
+
 func (r Range) Start() int64 {
 	return r.startInclusive
 }
@@ -35,41 +65,38 @@ func (r Range) End() int64 {
 	return r.endExclusive
 }
 
-func (r *Range) UnmarshalText(text []byte) error {
-	s := string(text)
-
-	if s == "" {
-		*r = Range{}
-		return nil
-	}
-
-	results := strings.Split(s, "-")
-	if len(results) == 0 || len(results) > 2 {
-		return fmt.Errorf("%w: %s", ErrInvalidRange, s)
+// Step returns the distance between consecutive IDs in r, which is 1 unless r is a stepped Range.
+func (r Range) Step() int64 {
+	if r.step <= 1 {
+		return 1
 	}
 
-	num1, err := strconv.ParseInt(results[0], 10, 64)
-	if err != nil {
-		return fmt.Errorf("%w: %s", ErrInvalidRange, s)
-	}
-	num2 := num1
+	return r.step
+}
 
-	if len(results) == 2 {
-		num2, err = strconv.ParseInt(results[1], 10, 64)
-		if err != nil {
-			return fmt.Errorf("%w: %s", ErrInvalidRange, s)
-		}
-	}
+// last returns the highest ID in a non-empty Range.
+func (r Range) last() int64 {
+	return r.endExclusive - 1
+}
 
-	if num2 < num1 {
-		return fmt.Errorf("%w: %s", ErrInvalidRange, s)
+// UnmarshalText parses a single Range. Besides the plain N and N-M forms, it accepts
+//
+//   - human suffixes on numbers: K, M, B and T (10M-2.5B),
+//   - an open upper bound, which is resolved against Frontier (9000000000-),
+//   - a step, which samples every step-th ID starting from the lower bound (1-1B/1000).
+//
+// Invalid text is reported as a *SyntaxError.
+func (r *Range) UnmarshalText(text []byte) error {
+	p := parser{text: string(text)}
+	rng, err := p.parseRange()
+	if err != nil {
+		return err
 	}
-
-	*r = Range{
-		startInclusive: num1,
-		endExclusive:   num2 + 1,
+	if err := p.expectEnd(); err != nil {
+		return err
 	}
 
+	*r = rng
 	return nil
 }
 
@@ -78,6 +105,10 @@ func (r Range) MarshalText() ([]byte, error) {
 		return []byte(""), nil
 	}
 
+	if r.Step() > 1 {
+		return []byte(fmt.Sprintf("%d-%d/%d", r.startInclusive, r.last(), r.step)), nil
+	}
+
 	return []byte(fmt.Sprintf("%d-%d", r.startInclusive, r.endExclusive-1)), nil
 }
 
@@ -89,6 +120,16 @@ func (r *Range) Pop(n int) Range {
 		return rCopy
 	}
 
+	if r.step > 1 {
+		if n <= 0 {
+			return Range{}
+		}
+
+		popped := newProgression(r.last()-int64(n-1)*r.step, r.last(), r.step)
+		*r = newProgression(r.startInclusive, popped.startInclusive-r.step, r.step)
+		return popped
+	}
+
 	oldEnd := r.endExclusive
 	r.endExclusive -= int64(n)
 	return Range{
@@ -97,17 +138,77 @@ func (r *Range) Pop(n int) Range {
 	}
 }
 
-func (r *Range) Len() int64 {
-	return r.endExclusive - r.startInclusive
+// PopFront is like Pop, but takes the lowest n IDs.
+func (r *Range) PopFront(n int) Range {
+	if r.Len() < int64(n) {
+		rCopy := *r
+		*r = Range{}
+		return rCopy
+	}
+
+	if r.step > 1 {
+		if n <= 0 {
+			return Range{}
+		}
+
+		popped := newProgression(r.startInclusive, r.startInclusive+int64(n-1)*r.step, r.step)
+		*r = newProgression(popped.last()+r.step, r.last(), r.step)
+		return popped
+	}
+
+	oldStart := r.startInclusive
+	r.startInclusive += int64(n)
+	return Range{
+		startInclusive: oldStart,
+		endExclusive:   r.startInclusive,
+	}
 }
 
+func (r Range) Len() int64 {
+	if r.endExclusive <= r.startInclusive {
+		return 0
+	}
+
+	step := r.Step()
+	return (r.endExclusive - r.startInclusive + step - 1) / step
+}
+
+// AsIntSlice returns every ID in r. Prefer Each, AppendIDs or Chunks for large Ranges.
 func (r Range) AsIntSlice() []int64 {
-	var entries []int64
-	for i := r.startInclusive; i < r.endExclusive; i++ {
-		entries = append(entries, i)
+	if r.Len() == 0 {
+		return nil
+	}
+
+	return r.AppendIDs(make([]int64, 0, r.Len()))
+}
+
+// AppendIDs appends every ID in r to dst in ascending order and returns the extended slice.
+// Passing a previously used buffer as dst[:0] avoids allocating.
+func (r Range) AppendIDs(dst []int64) []int64 {
+	step := r.Step()
+	for i := r.startInclusive; i < r.endExclusive; i += step {
+		dst = append(dst, i)
 	}
 
-	return entries
+	return dst
+}
+
+// Each calls fn for every ID in r in ascending order, stopping early if fn returns false.
+// It reports whether every ID was visited.
+func (r Range) Each(fn func(id int64) bool) bool {
+	step := r.Step()
+	for i := r.startInclusive; i < r.endExclusive; i += step {
+		if !fn(i) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Chunks returns a Chunker over the IDs in r.
+func (r Range) Chunks(n int) *Chunker {
+	return Ranges{r}.Chunks(n)
 }
 
 type Ranges []Range
@@ -115,18 +216,42 @@ type Ranges []Range
 var _ encoding.TextUnmarshaler = &Ranges{}
 var _ encoding.TextMarshaler = &Ranges{}
 
+// UnmarshalText parses a comma-separated list of Ranges, in the syntax accepted by Range.UnmarshalText.
+// Ranges prefixed with ! are excluded from the others, e.g. 1-1B,!500M-600M; since exclusions are applied
+// with Subtract, a list containing any exclusions comes out normalized.
+// Invalid text is reported as a *SyntaxError pointing at the offending token.
 func (r *Ranges) UnmarshalText(text []byte) error {
-	s := string(text)
-
-	list := strings.Split(s, ",")
-	*r = make(Ranges, len(list))
+	p := parser{text: string(text)}
 
-	for i := range list {
-		if err := (*r)[i].UnmarshalText([]byte(list[i])); err != nil {
+	var included, excluded Ranges
+	for {
+		exclude := p.consume('!')
+		rng, err := p.parseRange()
+		if err != nil {
 			return err
 		}
+
+		if exclude {
+			excluded = append(excluded, rng)
+		} else {
+			included = append(included, rng)
+		}
+
+		if !p.consume(',') {
+			break
+		}
 	}
 
+	if err := p.expectEnd(); err != nil {
+		return err
+	}
+
+	if len(excluded) > 0 {
+		*r = included.Subtract(excluded)
+		return nil
+	}
+
+	*r = included
 	return nil
 }
 
@@ -141,7 +266,12 @@ func (r Ranges) MarshalText() ([]byte, error) {
 }
 
 func (r *Ranges) Pop(n int) Ranges {
-	var newRange Ranges
+	return r.popInto(nil, n)
+}
+
+// popInto is Pop, but appends the popped Ranges to dst.
+func (r *Ranges) popInto(dst Ranges, n int) Ranges {
+	newRange := dst
 	var numEntries int64
 	for numEntries < int64(n) && len(*r) > 0 {
 		last := &(*r)[len(*r)-1]
@@ -155,11 +285,87 @@ func (r *Ranges) Pop(n int) Ranges {
 	return newRange
 }
 
+// PopFront is like Pop, but takes the lowest n IDs, starting from the first Range.
+func (r *Ranges) PopFront(n int) Ranges {
+	return r.popFrontInto(nil, n)
+}
+
+// popFrontInto is PopFront, but appends the popped Ranges to dst.
+func (r *Ranges) popFrontInto(dst Ranges, n int) Ranges {
+	newRange := dst
+	var numEntries int64
+	for numEntries < int64(n) && len(*r) > 0 {
+		first := &(*r)[0]
+		newRange = append(newRange, first.PopFront(n-int(numEntries)))
+		numEntries += newRange[len(newRange)-1].Len()
+		if first.Len() == 0 {
+			*r = (*r)[1:]
+		}
+	}
+
+	return newRange
+}
+
+// AsIntSlice returns every ID in r. Prefer Each, AppendIDs or Chunks for large Ranges.
 func (r Ranges) AsIntSlice() []int64 {
-	var entries []int64
+	if r.Len() == 0 {
+		return nil
+	}
+
+	return r.AppendIDs(make([]int64, 0, r.Len()))
+}
+
+// AppendIDs appends every ID in r to dst, Range by Range, and returns the extended slice.
+// Passing a previously used buffer as dst[:0] avoids allocating.
+func (r Ranges) AppendIDs(dst []int64) []int64 {
 	for i := range r {
-		entries = append(entries, r[i].AsIntSlice()...)
+		dst = r[i].AppendIDs(dst)
 	}
 
-	return entries
+	return dst
+}
+
+// Each calls fn for every ID in r, Range by Range, stopping early if fn returns false.
+// It reports whether every ID was visited.
+func (r Ranges) Each(fn func(id int64) bool) bool {
+	for i := range r {
+		if !r[i].Each(fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Chunker walks Ranges in batches of IDs using a single buffer, so that arbitrarily large Ranges can be
+// traversed in constant memory.
+type Chunker struct {
+	t    *Traversal
+	n    int
+	buf  []int64
+	last Ranges
+}
+
+// Chunks returns a Chunker yielding batches of at most n IDs from r, in the same order as Pop.
+// r itself is not modified.
+func (r Ranges) Chunks(n int) *Chunker {
+	return r.Traverse(Order{}).Chunks(n)
+}
+
+// Next returns the next batch of IDs, or nil once every ID has been returned.
+// The returned slice is reused by the following call to Next, so it must be copied to be retained.
+func (c *Chunker) Next() []int64 {
+	c.last = c.t.popInto(c.last[:0], c.n)
+	if c.last.Len() == 0 {
+		return nil
+	}
+
+	c.buf = c.last.AppendIDs(c.buf[:0])
+	return c.buf
+}
+
+// Ranges returns the Ranges covering the batch most recently returned by Next.
+// Like the batch itself, it is only valid until the following call to Next.
+func (c *Chunker) Ranges() Ranges {
+	return c.last
 }
@@ -49,4 +49,42 @@ func TestRanges(t *testing.T) {
 			assert.Equal(t, c.expectedResult, result)
 		}
 	})
+
+	t.Run("each", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 3), newRangeUnsafe(10, 11)}
+		assert.Equal(t, []int64{1, 2, 3, 10, 11}, rngs.AsIntSlice())
+
+		var visited []int64
+		assert.False(t, rngs.Each(func(id int64) bool {
+			visited = append(visited, id)
+			return id < 10
+		}))
+		assert.Equal(t, []int64{1, 2, 3, 10}, visited)
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 3), newRangeUnsafe(10, 14)}
+		c := rngs.Chunks(4)
+
+		assert.Equal(t, []int64{11, 12, 13, 14}, c.Next())
+		assert.Equal(t, Ranges{newRangeUnsafe(11, 14)}, c.Ranges())
+		assert.Equal(t, []int64{10, 1, 2, 3}, c.Next())
+		assert.Equal(t, Ranges{newRangeUnsafe(10, 10), newRangeUnsafe(1, 3)}, c.Ranges())
+		assert.Nil(t, c.Next())
+
+		// the original Ranges is left untouched
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 3), newRangeUnsafe(10, 14)}, rngs)
+	})
+
+	t.Run("chunks without allocating", func(t *testing.T) {
+		c := Ranges{newRangeUnsafe(1, 10_000_000_000)}.Chunks(256)
+		c.Next()
+
+		var n int
+		allocs := testing.AllocsPerRun(1000, func() {
+			n = len(c.Next())
+		})
+		assert.Equal(t, 256, n)
+		assert.Zero(t, allocs)
+	})
 }
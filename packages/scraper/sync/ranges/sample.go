@@ -0,0 +1,33 @@
+package ranges
+
+import "sort"
+
+// Sample returns n distinct IDs of r drawn uniformly at random without replacement, as a normalized Ranges.
+// The same seed always draws the same IDs from the same Ranges. If r holds at most n IDs, all of them are returned.
+func (r Ranges) Sample(n int64, seed int64) Ranges {
+	rngs := r.Normalize()
+	total := rngs.Len()
+	if n >= total {
+		return rngs
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	offsets := rngs.offsets()
+	perm := newPermutation(uint64(total), seed)
+	ids := make([]int64, 0, n)
+	for i := int64(0); i < n; i++ {
+		ordinal := int64(perm.at(uint64(i)))
+		j := sort.Search(len(offsets), func(j int) bool { return offsets[j] > ordinal }) - 1
+		ids = append(ids, rngs[j].startInclusive+(ordinal-offsets[j])*rngs[j].Step())
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	sample := make(Ranges, 0, len(ids))
+	for _, id := range ids {
+		sample = append(sample, Range{startInclusive: id, endExclusive: id + 1})
+	}
+
+	return sample.Normalize()
+}
@@ -0,0 +1,25 @@
+package ranges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSample(t *testing.T) {
+	rngs := Ranges{newRangeUnsafe(1, 1_000_000), newSteppedRangeUnsafe(5_000_000_000, 6_000_000_000, 1000)}
+
+	sample := rngs.Sample(2000, 42)
+	assert.Equal(t, int64(2000), sample.Len())
+	assert.True(t, sample.IsNormalized())
+	assert.Empty(t, sample.Subtract(rngs), "every sampled ID belongs to the Ranges")
+	assert.Equal(t, sample, rngs.Sample(2000, 42))
+	assert.NotEqual(t, sample, rngs.Sample(2000, 43))
+
+	// both Ranges hold a million IDs, so each should get roughly half of the sample
+	inFirst := sample.Intersect(rngs[:1]).Len()
+	assert.InDelta(t, 1000, inFirst, 150)
+
+	assert.Equal(t, rngs.Normalize(), Ranges{newRangeUnsafe(1, 10)}.Union(rngs).Sample(rngs.Len()+10, 1))
+	assert.Empty(t, rngs.Sample(0, 1))
+}
@@ -0,0 +1,351 @@
+package ranges
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Contains reports whether id lies within r.
+func (r Range) Contains(id int64) bool {
+	return r.startInclusive <= id && id < r.endExclusive && (id-r.startInclusive)%r.Step() == 0
+}
+
+// Overlaps reports whether r and other have at least one ID in common.
+func (r Range) Overlaps(other Range) bool {
+	return r.Intersect(other).Len() > 0
+}
+
+// Intersect returns the IDs common to r and other, or the empty Range if there are none.
+// The intersection of two stepped Ranges steps by the least common multiple of their steps.
+func (r Range) Intersect(other Range) Range {
+	if r.Len() == 0 || other.Len() == 0 {
+		return Range{}
+	}
+
+	lo, hi := r.startInclusive, r.last()
+	if other.startInclusive > lo {
+		lo = other.startInclusive
+	}
+	if other.last() < hi {
+		hi = other.last()
+	}
+	if hi < lo {
+		return Range{}
+	}
+
+	if r.Step() == 1 && other.Step() == 1 {
+		return Range{startInclusive: lo, endExclusive: hi + 1}
+	}
+
+	// Solve x = r.start (mod a), x = other.start (mod b) by the Chinese remainder theorem.
+	// The solutions step by lcm(a, b), which can overflow an int64, so work in big integers.
+	a, b := big.NewInt(r.Step()), big.NewInt(other.Step())
+	g := new(big.Int).GCD(nil, nil, a, b)
+	diff := big.NewInt(other.startInclusive - r.startInclusive)
+	if new(big.Int).Rem(diff, g).Sign() != 0 {
+		return Range{}
+	}
+
+	ag, bg := new(big.Int).Quo(a, g), new(big.Int).Quo(b, g)
+	k := new(big.Int)
+	if bg.Cmp(big.NewInt(1)) != 0 {
+		k.ModInverse(ag, bg)
+		k.Mul(k, new(big.Int).Quo(diff, g))
+		k.Mod(k, bg)
+	}
+	lcm := new(big.Int).Mul(ag, b)
+	solution := new(big.Int).Add(big.NewInt(r.startInclusive), k.Mul(k, a))
+
+	// move to the first solution at or after lo
+	offset := new(big.Int).Sub(big.NewInt(lo), solution)
+	offset.Neg(offset).Div(offset, lcm).Neg(offset) // ceil((lo - solution) / lcm)
+	first := solution.Add(solution, offset.Mul(offset, lcm))
+	if first.Cmp(big.NewInt(hi)) > 0 {
+		return Range{}
+	}
+
+	if !lcm.IsInt64() || lcm.Int64() > hi-first.Int64() {
+		return Range{startInclusive: first.Int64(), endExclusive: first.Int64() + 1}
+	}
+
+	step := lcm.Int64()
+	return newProgression(first.Int64(), first.Int64()+(hi-first.Int64())/step*step, step)
+}
+
+// clip returns the IDs of r within [lo, hi].
+func (r Range) clip(lo, hi int64) Range {
+	if r.Len() == 0 {
+		return Range{}
+	}
+
+	if lo < r.startInclusive {
+		lo = r.startInclusive
+	}
+	if hi > r.last() {
+		hi = r.last()
+	}
+	if hi < lo {
+		return Range{}
+	}
+
+	step := r.Step()
+	return newProgression(
+		r.startInclusive+(lo-r.startInclusive+step-1)/step*step,
+		r.startInclusive+(hi-r.startInclusive)/step*step,
+		step,
+	)
+}
+
+// subtract returns the IDs of r that aren't in other, as disjoint Ranges in ascending order of their start.
+func (r Range) subtract(other Range) Ranges {
+	common := r.Intersect(other)
+	if common.Len() == 0 {
+		return Ranges{r}
+	}
+
+	var result Ranges
+	if before := r.clip(r.startInclusive, common.startInclusive-1); before.Len() > 0 {
+		result = append(result, before)
+	}
+
+	// Between the first and last IDs in common, r holds k-1 IDs between each consecutive pair of common IDs,
+	// since common steps by a multiple k of r's step. Whichever of the gaps or the residues is fewer in number
+	// makes for the shorter list.
+	if step, k := r.Step(), common.Step()/r.Step(); common.Len() > 1 && k > 1 {
+		if common.Len()-1 <= k-1 {
+			for id := common.startInclusive; id < common.last(); id += common.step {
+				result = append(result, newProgression(id+step, id+common.step-step, step))
+			}
+		} else {
+			for j := int64(1); j < k; j++ {
+				result = append(result, newProgression(common.startInclusive+j*step, common.last()-common.step+j*step, common.step))
+			}
+		}
+	}
+
+	if after := r.clip(common.last()+1, r.last()); after.Len() > 0 {
+		result = append(result, after)
+	}
+
+	return result
+}
+
+// Len returns the number of IDs in r, counting IDs covered by several Ranges once per Range.
+// Call Normalize first to count distinct IDs.
+func (r Ranges) Len() int64 {
+	var n int64
+	for i := range r {
+		n += r[i].Len()
+	}
+
+	return n
+}
+
+// stepped reports whether any Range in r is a stepped Range.
+func (r Ranges) stepped() bool {
+	for i := range r {
+		if r[i].Step() > 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Normalize returns the IDs covered by r as a list of disjoint Ranges sorted in ascending order of their start,
+// with empty Ranges removed and adjacent Ranges merged. r itself is not modified.
+//
+// The text representation of a normalized Ranges without steps is canonical: any two such Ranges covering
+// the same IDs marshal to the same text once normalized.
+func (r Ranges) Normalize() Ranges {
+	sorted := make(Ranges, 0, len(r))
+	for _, rng := range r {
+		if rng.Len() > 0 {
+			sorted = append(sorted, rng)
+		}
+	}
+
+	if sorted.stepped() {
+		// make the Ranges disjoint first, since stepped Ranges can interleave without overlapping
+		var disjoint Ranges
+		for _, rng := range sorted {
+			pieces := Ranges{rng}
+			for _, other := range disjoint {
+				pieces = pieces.subtractRange(other)
+			}
+			disjoint = append(disjoint, pieces...)
+		}
+		sorted = disjoint
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].startInclusive != sorted[j].startInclusive {
+			return sorted[i].startInclusive < sorted[j].startInclusive
+		}
+		return sorted[i].endExclusive < sorted[j].endExclusive
+	})
+
+	normalized := make(Ranges, 0, len(sorted))
+	for _, rng := range sorted {
+		if n := len(normalized); n > 0 {
+			if merged, ok := merge(normalized[n-1], rng); ok {
+				normalized[n-1] = merged
+				continue
+			}
+		}
+
+		normalized = append(normalized, rng)
+	}
+
+	return normalized
+}
+
+// merge joins prev and next into a single Range if their union is one, given that next doesn't start before prev.
+// Contiguous Ranges may overlap; stepped Ranges must be disjoint.
+func merge(prev, next Range) (Range, bool) {
+	switch {
+	case prev.Step() == 1 && next.Step() == 1:
+		if next.startInclusive > prev.endExclusive {
+			return Range{}, false
+		}
+		if next.endExclusive > prev.endExclusive {
+			prev.endExclusive = next.endExclusive
+		}
+		return prev, true
+	case prev.Len() == 1 && next.startInclusive == prev.startInclusive+next.Step():
+		return newProgression(prev.startInclusive, next.last(), next.Step()), true
+	case next.Len() == 1 && next.startInclusive == prev.last()+prev.Step():
+		return newProgression(prev.startInclusive, next.startInclusive, prev.Step()), true
+	case prev.Step() == next.Step() && next.startInclusive == prev.last()+prev.Step():
+		return newProgression(prev.startInclusive, next.last(), prev.Step()), true
+	default:
+		return Range{}, false
+	}
+}
+
+// IsNormalized reports whether r is already in the form returned by Normalize.
+func (r Ranges) IsNormalized() bool {
+	normalized := r.Normalize()
+	if len(normalized) != len(r) {
+		return false
+	}
+
+	for i := range r {
+		if r[i] != normalized[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Union returns the normalized set of IDs that are in r or other.
+func (r Ranges) Union(other Ranges) Ranges {
+	all := make(Ranges, 0, len(r)+len(other))
+	all = append(all, r...)
+	all = append(all, other...)
+
+	return all.Normalize()
+}
+
+// Intersect returns the normalized set of IDs that are in both r and other.
+func (r Ranges) Intersect(other Ranges) Ranges {
+	a, b := r.Normalize(), other.Normalize()
+
+	var result Ranges
+	if a.stepped() || b.stepped() {
+		for i := range a {
+			for j := range b {
+				if rng := a[i].Intersect(b[j]); rng.Len() > 0 {
+					result = append(result, rng)
+				}
+			}
+		}
+
+		return result.Normalize()
+	}
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if rng := a[i].Intersect(b[j]); rng.Len() > 0 {
+			result = append(result, rng)
+		}
+
+		// advance whichever Range ends first; it can't overlap anything else in the other list
+		if a[i].endExclusive < b[j].endExclusive {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return result
+}
+
+// Subtract returns the normalized set of IDs that are in r but not in other.
+func (r Ranges) Subtract(other Ranges) Ranges {
+	a, b := r.Normalize(), other.Normalize()
+
+	if a.stepped() || b.stepped() {
+		for _, rng := range b {
+			a = a.subtractRange(rng)
+		}
+
+		return a.Normalize()
+	}
+
+	var result Ranges
+	j := 0
+	for _, rng := range a {
+		// skip the subtrahends that end before this Range starts
+		for j < len(b) && b[j].endExclusive <= rng.startInclusive {
+			j++
+		}
+
+		for k := j; k < len(b) && b[k].startInclusive < rng.endExclusive; k++ {
+			if b[k].startInclusive > rng.startInclusive {
+				result = append(result, Range{startInclusive: rng.startInclusive, endExclusive: b[k].startInclusive})
+			}
+			rng.startInclusive = b[k].endExclusive
+		}
+
+		if rng.Len() > 0 {
+			result = append(result, rng)
+		}
+	}
+
+	return result
+}
+
+// subtractRange removes the IDs of other from each Range in r.
+func (r Ranges) subtractRange(other Range) Ranges {
+	result := make(Ranges, 0, len(r))
+	for i := range r {
+		result = append(result, r[i].subtract(other)...)
+	}
+
+	return result
+}
+
+// Contains reports whether id lies within any of the Ranges in r.
+func (r Ranges) Contains(id int64) bool {
+	for i := range r {
+		if r[i].Contains(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Overlaps reports whether r and other have at least one ID in common.
+func (r Ranges) Overlaps(other Ranges) bool {
+	for i := range r {
+		for j := range other {
+			if r[i].Overlaps(other[j]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+package ranges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	t.Run("normalize", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(20, 30), {}, newRangeUnsafe(1, 10), newRangeUnsafe(5, 12), newRangeUnsafe(13, 15), newRangeUnsafe(25, 26)}
+		normalized := rngs.Normalize()
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 15), newRangeUnsafe(20, 30)}, normalized)
+		assert.True(t, normalized.IsNormalized())
+		assert.False(t, rngs.IsNormalized())
+
+		txt, err := normalized.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, "1-15,20-30", string(txt))
+	})
+
+	t.Run("union", func(t *testing.T) {
+		a := Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(30, 40)}
+		b := Ranges{newRangeUnsafe(8, 20), newRangeUnsafe(41, 41)}
+		assert.Equal(t, Ranges{newRangeUnsafe(1, 20), newRangeUnsafe(30, 41)}, a.Union(b))
+	})
+
+	t.Run("intersect", func(t *testing.T) {
+		a := Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(30, 40)}
+		b := Ranges{newRangeUnsafe(5, 35), newRangeUnsafe(38, 100)}
+		assert.Equal(t, Ranges{newRangeUnsafe(5, 10), newRangeUnsafe(30, 35), newRangeUnsafe(38, 40)}, a.Intersect(b))
+		assert.Empty(t, a.Intersect(Ranges{newRangeUnsafe(11, 29)}))
+	})
+
+	t.Run("subtract", func(t *testing.T) {
+		type testCase struct {
+			a, b     Ranges
+			expected Ranges
+		}
+
+		cases := []testCase{
+			{
+				a:        Ranges{newRangeUnsafe(1, 10_000_000_000)},
+				b:        Ranges{newRangeUnsafe(9_999_997_501, 10_000_000_000), newRangeUnsafe(1, 2500)},
+				expected: Ranges{newRangeUnsafe(2501, 9_999_997_500)},
+			},
+			{
+				a:        Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(20, 30)},
+				b:        Ranges{newRangeUnsafe(3, 4), newRangeUnsafe(6, 22), newRangeUnsafe(30, 30)},
+				expected: Ranges{newRangeUnsafe(1, 2), newRangeUnsafe(5, 5), newRangeUnsafe(23, 29)},
+			},
+			{
+				a:        Ranges{newRangeUnsafe(1, 10)},
+				b:        Ranges{newRangeUnsafe(0, 100)},
+				expected: nil,
+			},
+		}
+
+		for _, c := range cases {
+			assert.Equal(t, c.expected, c.a.Subtract(c.b))
+		}
+	})
+
+	t.Run("contains", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 10), newRangeUnsafe(30, 40)}
+		assert.True(t, rngs.Contains(1))
+		assert.True(t, rngs.Contains(40))
+		assert.False(t, rngs.Contains(11))
+		assert.False(t, rngs.Contains(41))
+
+		assert.True(t, rngs.Overlaps(Ranges{newRangeUnsafe(10, 12)}))
+		assert.False(t, rngs.Overlaps(Ranges{newRangeUnsafe(11, 29)}))
+	})
+}
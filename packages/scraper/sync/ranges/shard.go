@@ -0,0 +1,44 @@
+package ranges
+
+import "math/bits"
+
+// Split partitions the IDs of r into n disjoint Ranges of nearly equal size: their lengths differ by at most one.
+// The partitions are taken in order from the normalized form of r, so the result depends only on the IDs in r,
+// not on how r is written. Split returns nil if n isn't positive.
+func (r Ranges) Split(n int) []Ranges {
+	if n <= 0 {
+		return nil
+	}
+
+	normalized := r.Normalize()
+	offsets := normalized.offsets()
+	total := normalized.Len()
+
+	parts := make([]Ranges, n)
+	for i := range parts {
+		parts[i] = normalized.slice(offsets, partitionBound(total, i, n), partitionBound(total, i+1, n))
+	}
+
+	return parts
+}
+
+// Shard returns the i-th of the n partitions produced by Split, counting from zero, without computing the others.
+// Separate processes can each take a different shard of the same Ranges without coordinating.
+// Shard returns an empty Ranges if i isn't in [0, n).
+func (r Ranges) Shard(i, n int) Ranges {
+	if n <= 0 || i < 0 || i >= n {
+		return nil
+	}
+
+	normalized := r.Normalize()
+	total := normalized.Len()
+
+	return normalized.slice(normalized.offsets(), partitionBound(total, i, n), partitionBound(total, i+1, n))
+}
+
+// partitionBound returns floor(total * i / n) without overflowing, for 0 <= i <= n.
+func partitionBound(total int64, i, n int) int64 {
+	hi, lo := bits.Mul64(uint64(total), uint64(i))
+	quo, _ := bits.Div64(hi, lo, uint64(n))
+	return int64(quo)
+}
@@ -0,0 +1,54 @@
+package ranges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShard(t *testing.T) {
+	t.Run("split", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(21, 30), newRangeUnsafe(1, 10), newRangeUnsafe(5, 12)}
+		parts := rngs.Split(3)
+
+		assert.Equal(t, []Ranges{
+			{newRangeUnsafe(1, 7)},
+			{newRangeUnsafe(8, 12), newRangeUnsafe(21, 22)},
+			{newRangeUnsafe(23, 30)},
+		}, parts)
+
+		var union Ranges
+		for i, part := range parts {
+			assert.Equal(t, part, rngs.Shard(i, 3))
+			assert.False(t, union.Overlaps(part))
+			union = union.Union(part)
+		}
+		assert.Equal(t, rngs.Normalize(), union)
+	})
+
+	t.Run("balanced", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 10_000_000_000), newSteppedRangeUnsafe(20_000_000_000, 30_000_000_000, 7)}
+		total := rngs.Len()
+
+		for _, n := range []int{1, 7, 100, 4096} {
+			min, max := total, int64(0)
+			for i := 0; i < n; i++ {
+				l := rngs.Shard(i, n).Len()
+				if l < min {
+					min = l
+				}
+				if l > max {
+					max = l
+				}
+			}
+			assert.LessOrEqual(t, max-min, int64(1), n)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rngs := Ranges{newRangeUnsafe(1, 10)}
+		assert.Nil(t, rngs.Split(0))
+		assert.Empty(t, rngs.Shard(3, 3))
+		assert.Empty(t, rngs.Shard(-1, 3))
+	})
+}
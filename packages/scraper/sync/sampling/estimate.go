@@ -0,0 +1,48 @@
+package sampling
+
+import "math"
+
+// Estimate is an estimated number of assets in a region, with a confidence interval.
+type Estimate struct {
+	Count float64
+	Low   float64
+	High  float64
+}
+
+// EstimateCount estimates how many IDs in a population of the given size hold an asset,
+// given that found of sampled IDs drawn without replacement did.
+//
+// The interval is the Wilson score interval at the given confidence level (e.g. 0.95),
+// narrowed by the finite population correction, and clamped to the counts that are still possible
+// given what the sample has already seen.
+func EstimateCount(found, sampled, population int64, confidence float64) Estimate {
+	if sampled <= 0 || population <= 0 {
+		return Estimate{Low: 0, High: float64(population)}
+	}
+
+	N, n := float64(population), float64(sampled)
+	p := float64(found) / n
+	est := Estimate{Count: p * N}
+
+	// a census leaves no uncertainty
+	fpc := 1.0
+	if population > 1 {
+		fpc = (N - n) / (N - 1)
+	}
+	if fpc <= 0 {
+		est.Low, est.High = est.Count, est.Count
+		return est
+	}
+
+	// sampling without replacement has the variance of a sample with replacement that is 1/fpc times larger
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	nEff := n / fpc
+	denom := 1 + z*z/nEff
+	center := (p + z*z/(2*nEff)) / denom
+	half := z * math.Sqrt(p*(1-p)/nEff+z*z/(4*nEff*nEff)) / denom
+
+	est.Low = math.Max((center-half)*N, float64(found))
+	est.High = math.Min((center+half)*N, N-float64(sampled-found))
+
+	return est
+}
@@ -0,0 +1,65 @@
+package sampling
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateCount(t *testing.T) {
+	t.Run("wilson interval", func(t *testing.T) {
+		// with a huge population, this is the textbook Wilson interval: 10/100 at 95% is about [5.5%, 17.4%]
+		est := EstimateCount(10, 100, 1_000_000_000, 0.95)
+		assert.InDelta(t, 100_000_000, est.Count, 1)
+		assert.InDelta(t, 0.0552, est.Low/1e9, 0.0005)
+		assert.InDelta(t, 0.1744, est.High/1e9, 0.0005)
+	})
+
+	t.Run("finite population", func(t *testing.T) {
+		wide := EstimateCount(10, 100, 1_000_000, 0.95)
+		narrow := EstimateCount(10, 100, 200, 0.95)
+		assert.Less(t, narrow.High/200-narrow.Low/200, wide.High/1e6-wide.Low/1e6)
+
+		census := EstimateCount(10, 200, 200, 0.95)
+		assert.Equal(t, Estimate{Count: 10, Low: 10, High: 10}, census)
+
+		// everything not sampled could still be an asset, but nothing sampled can be taken back
+		est := EstimateCount(3, 195, 200, 0.99)
+		assert.GreaterOrEqual(t, est.Low, 3.0)
+		assert.LessOrEqual(t, est.High, 8.0)
+	})
+
+	t.Run("nothing sampled", func(t *testing.T) {
+		assert.Equal(t, Estimate{Low: 0, High: 500}, EstimateCount(0, 0, 500, 0.95))
+	})
+
+	t.Run("coverage", func(t *testing.T) {
+		// the 95% interval should contain the true count in roughly 95% of samples
+		rnd := rand.New(rand.NewSource(1))
+		const population, assets, sampled, trials = 100_000, 2_000, 1_000, 2_000
+
+		covered := 0
+		for i := 0; i < trials; i++ {
+			found := int64(0)
+			seen := make(map[int]bool, sampled)
+			for len(seen) < sampled {
+				ordinal := rnd.Intn(population)
+				if seen[ordinal] {
+					continue
+				}
+				seen[ordinal] = true
+				if ordinal < assets {
+					found++
+				}
+			}
+
+			est := EstimateCount(found, sampled, population, 0.95)
+			if est.Low <= assets && assets <= est.High {
+				covered++
+			}
+		}
+
+		assert.InDelta(t, 0.95, float64(covered)/trials, 0.02)
+	})
+}
@@ -0,0 +1,98 @@
+// Package sampling estimates how many assets of each type live in a region of IDs
+// by querying a random sample of its IDs, before committing to a full sync of the region.
+package sampling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"golang.org/x/time/rate"
+)
+
+// Region is a region of IDs together with the IDs sampled from it.
+type Region struct {
+	Ranges ranges.Ranges
+	Sample ranges.Ranges
+}
+
+// Population returns the number of IDs in the region.
+func (r Region) Population() int64 {
+	return r.Ranges.Len()
+}
+
+// Plan splits rngs into the given number of equally sized regions and samples perRegion IDs from each.
+// The same seed always yields the same plan.
+func Plan(rngs ranges.Ranges, regions int, perRegion int64, seed int64) []Region {
+	parts := rngs.Split(regions)
+	plan := make([]Region, len(parts))
+	for i, part := range parts {
+		plan[i] = Region{
+			Ranges: part,
+			Sample: part.Sample(perRegion, seed+int64(i)),
+		}
+	}
+
+	return plan
+}
+
+// Tally counts the assets of each type found among the sampled IDs.
+type Tally struct {
+	// Sampled is the number of IDs successfully looked up.
+	Sampled int64 `json:"sampled"`
+	// Failed is the number of IDs whose batch request failed. They don't count towards Sampled.
+	Failed int64 `json:"failed"`
+	// Counts maps asset types to the number of sampled IDs holding an asset of that type.
+	Counts map[assetdelivery.AssetType]int64 `json:"counts"`
+}
+
+// Add adds the counts of other to t.
+func (t *Tally) Add(other Tally) {
+	t.Sampled += other.Sampled
+	t.Failed += other.Failed
+	for assetType, n := range other.Counts {
+		if t.Counts == nil {
+			t.Counts = make(map[assetdelivery.AssetType]int64)
+		}
+		t.Counts[assetType] += n
+	}
+}
+
+// Batcher looks up the descriptions of a batch of asset IDs. It is implemented by *assetdelivery.Client.
+type Batcher interface {
+	Batch(ctx context.Context, ids []int64, opts *assetdelivery.BatchOptions) (assetdelivery.AssetDescriptions, error)
+}
+
+var _ Batcher = &assetdelivery.Client{}
+
+// Run looks up every ID of sample, waiting on limiter before each batch request, and tallies the asset types found.
+// IDs without an asset count towards Sampled but not towards any asset type.
+// A failed batch request is counted in Tally.Failed rather than aborting the run, unless ctx is done.
+func Run(ctx context.Context, b Batcher, sample ranges.Ranges, limiter *rate.Limiter) (Tally, error) {
+	tally := Tally{Counts: make(map[assetdelivery.AssetType]int64)}
+
+	chunks := sample.Traverse(ranges.Order{Strategy: ranges.Ascending}).Chunks(assetdelivery.MaxBatchSize)
+	for ids := chunks.Next(); len(ids) > 0; ids = chunks.Next() {
+		if err := limiter.Wait(ctx); err != nil {
+			return tally, err
+		}
+
+		descriptions, err := b.Batch(ctx, ids, &assetdelivery.BatchOptions{SkipSigningScripts: true})
+		if err != nil {
+			if ctx.Err() != nil {
+				return tally, fmt.Errorf("sample batch: %w", err)
+			}
+
+			tally.Failed += int64(len(ids))
+			continue
+		}
+
+		tally.Sampled += int64(len(ids))
+		for _, item := range descriptions.DiscardErrored() {
+			tally.Counts[item.AssetTypeID]++
+		}
+	}
+
+	return tally, nil
+}
@@ -0,0 +1,85 @@
+package sampling
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"golang.org/x/time/rate"
+)
+
+// fakeBatcher holds a model at every ID divisible by 10, a decal at every other odd ID,
+// and fails every batch starting at an ID in fail.
+type fakeBatcher struct {
+	fail  map[int64]bool
+	calls int
+}
+
+func (f *fakeBatcher) Batch(_ context.Context, ids []int64, _ *assetdelivery.BatchOptions) (assetdelivery.AssetDescriptions, error) {
+	f.calls++
+	if len(ids) > assetdelivery.MaxBatchSize {
+		return nil, errors.New("too many IDs")
+	}
+	if f.fail[ids[0]] {
+		return nil, errors.New("forbidden")
+	}
+
+	descriptions := make(assetdelivery.AssetDescriptions, len(ids))
+	for i, id := range ids {
+		descriptions[i].AssetID = id
+		switch {
+		case id%10 == 0:
+			descriptions[i].AssetTypeID = assetdelivery.Model
+		case id%4 == 1:
+			descriptions[i].AssetTypeID = assetdelivery.Decal
+		default:
+			descriptions[i].Errors = assetdelivery.Errors{{Code: 404, Message: "Asset is not approved for the requester"}}
+		}
+	}
+
+	return descriptions, nil
+}
+
+func TestPlan(t *testing.T) {
+	rng, err := ranges.NewRange(1, 1_000_000)
+	require.NoError(t, err)
+
+	plan := Plan(ranges.Ranges{rng}, 4, 100, 7)
+	require.Len(t, plan, 4)
+	for _, region := range plan {
+		assert.Equal(t, int64(250_000), region.Population())
+		assert.Equal(t, int64(100), region.Sample.Len())
+		assert.Empty(t, region.Sample.Subtract(region.Ranges))
+	}
+	assert.Equal(t, plan, Plan(ranges.Ranges{rng}, 4, 100, 7))
+}
+
+func TestRun(t *testing.T) {
+	rng, err := ranges.NewRange(1, 1000)
+	require.NoError(t, err)
+
+	b := &fakeBatcher{fail: map[int64]bool{257: true}}
+	tally, err := Run(context.Background(), b, ranges.Ranges{rng}, rate.NewLimiter(rate.Inf, 1))
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, b.calls)
+	assert.Equal(t, int64(256), tally.Failed)
+	assert.Equal(t, int64(744), tally.Sampled)
+	// IDs 257-512 failed, taking 26 models and 64 decals with them
+	assert.Equal(t, map[assetdelivery.AssetType]int64{assetdelivery.Model: 100 - 26, assetdelivery.Decal: 250 - 64}, tally.Counts)
+
+	var total Tally
+	total.Add(tally)
+	total.Add(tally)
+	assert.Equal(t, int64(1488), total.Sampled)
+	assert.Equal(t, int64(148), total.Counts[assetdelivery.Model])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, b, ranges.Ranges{rng}, rate.NewLimiter(rate.Every(1), 1))
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -8,15 +8,16 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"strings"
-	"sync"
+	"strconv"
 	"time"
 
-	tls "github.com/refraction-networking/utls"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
 	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
-	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/proxypool"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/sampling"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -33,6 +34,15 @@ var (
 	key, secret, bucket, region string
 )
 
+const (
+	// indexWorkers is the most batch requests in flight at once.
+	indexWorkers = 16
+	// versionWorkers is the most assets whose versions are walked at once.
+	versionWorkers = 8
+	// minBatchesToGiveUp is the fewest batch results that indexLoop sees before judging that too many are blocked.
+	minBatchesToGiveUp = 8
+)
+
 func Main(in client.Request) (*client.Response, error) {
 	l, err := logrus.ParseLevel(os.Getenv("LOG_LEVEL"))
 	if err != nil {
@@ -41,6 +51,23 @@ func Main(in client.Request) (*client.Response, error) {
 		logrus.SetLevel(l)
 	}
 
+	if in.Sample {
+		return sample(in)
+	}
+
+	if err := loadConfig(); err != nil {
+		return nil, err
+	}
+
+	indexer, n, err := newIndexer(in)
+	if err != nil {
+		return nil, err
+	}
+	downloader, err := newDownloader(os.Getenv("CDN_PROXY"))
+	if err != nil {
+		return nil, fmt.Errorf("CDN_PROXY: %w", err)
+	}
+
 	items := make(chan assetdelivery.AssetDescription, 10_000)
 	eg, eCtx := errgroup.WithContext(context.Background())
 
@@ -56,7 +83,8 @@ func Main(in client.Request) (*client.Response, error) {
 
 	uploader := manager.NewUploader(s3Client)
 
-	eg.Go(func() error { return indexLoop(eCtx, eg, in.Ranges, items, time.Second) })
+	var results outcomes
+	eg.Go(func() error { return indexLoop(eCtx, indexer, in, items, &results, time.Second) })
 	if in.Concurrency == 0 {
 		in.Concurrency = 4
 	}
@@ -80,56 +108,40 @@ func Main(in client.Request) (*client.Response, error) {
 					}
 					numItems.Inc()
 
-					ctx, cancel := context.WithTimeout(eCtx, time.Second*5)
-
 					logger := logrus.WithField("item", item)
-					req, err := http.NewRequestWithContext(ctx, http.MethodGet, item.Locations[0].Location, nil)
-					if err != nil {
-						cancel()
-						return fmt.Errorf("error creating request: %w", err)
+					if item.AssetID == 0 {
+						// requested by asset version ID, so there's no asset ID to record or store it under
+						logger.Error("asset has no asset ID, skipping")
+						continue
 					}
+					// assume failure until every upload succeeds
+					results.add(&results.failed, item.AssetID)
 
-					logger.Trace("initializing download")
-					resp, err := http.DefaultClient.Do(req)
-					if err != nil {
-						cancel()
-						logger.WithError(err).Error("failed to get asset, skipping")
+					locations := locationsToStore(in, item)
+					if len(locations) == 0 {
+						logger.Error("asset has no locations, skipping")
 						continue
 					}
-					logger.Trace("initialized download")
-
-					pr, pw := io.Pipe()
-					go func() {
-						gz.Reset(pw)
-						defer gz.Reset(nil)
-						if _, err := io.Copy(gz, resp.Body); err != nil {
-							logger.WithError(err).Error("couldn't stream response body")
-							pw.CloseWithError(err)
-							return
-						}
-						if err := gz.Close(); err != nil {
-							logger.WithError(err).Error("couldn't close/flush gzip writer")
-						}
 
-						pw.Close()
-					}()
-
-					logger.Trace("initializing s3 upload")
-					_, err = uploader.Upload(ctx, &s3.PutObjectInput{
-						Bucket: aws.String(bucket),
-						Key:    aws.String(item.Etag() + ".gz"),
-						Body:   pr,
-					})
-					logger.Trace("finished s3 upload")
-					if err != nil {
-						cancel()
-						logger.WithError(err).Error("couldn't upload to s3")
+					stored := true
+					for _, loc := range locations {
+						if err := store(eCtx, downloader, uploader, gz, item, loc, objectKey(item, loc, in.AllFormats)); err != nil {
+							if eCtx.Err() != nil {
+								return eCtx.Err()
+							}
+							logger.WithError(err).WithField("format", loc.AssetFormat).Error("couldn't store asset, skipping")
+							stored = false
+						}
+					}
+					if !stored {
 						continue
 					}
-					pr.Close()
 
-					cancel()
 					numSuccess.Inc()
+					results.markDownloaded(item.AssetID)
+					if item.Version != 0 {
+						results.markVersionStored(item.AssetID, item.Version)
+					}
 				}
 			}
 		})
@@ -140,37 +152,190 @@ func Main(in client.Request) (*client.Response, error) {
 		return nil, err
 	}
 
-	return &client.Response{
+	resp := &client.Response{
 		StatusCode:           http.StatusOK,
 		Successes:            int(numSuccess.Load()),
 		Failures:             int(numItems.Load() - numSuccess.Load()),
 		Total:                int(numItems.Load()),
 		DurationMilliseconds: int(time.Since(t0).Milliseconds()),
-	}, nil
+		Indexed:              &results.indexed,
+		Found:                &results.found,
+		Downloaded:           &results.downloaded,
+		Failed:               &results.failed,
+		Retries:              results.retries,
+		Versions:             results.versions,
+	}
+	n.report(resp)
+
+	return resp, nil
 }
 
-func newClientWithOptions(proxy string) (*resty.Client, error) {
-	return resty.New().
-		SetRetryCount(3).
-		SetTransport(&http.Transport{
-			DialTLS: func(network, addr string) (net.Conn, error) {
-				dialConn, err := net.Dial(network, addr)
-				if err != nil {
-					return nil, err
-				}
+// locationsToStore returns the locations of item that in stores: all of them with in.AllFormats set,
+// or else the one in the most preferred of in.Formats.
+func locationsToStore(in client.Request, item assetdelivery.AssetDescription) assetdelivery.Locations {
+	if in.AllFormats {
+		return item.Locations
+	}
 
-				config := tls.Config{
-					ServerName: strings.Split(addr, ":")[0],
-				}
+	loc, ok := item.Locations.Preferred(in.Formats...)
+	if !ok {
+		return nil
+	}
 
-				uTLSConn := tls.UClient(dialConn, &config, tls.HelloRandomizedALPN)
-				if err := uTLSConn.Handshake(); err != nil {
-					return nil, err
-				}
-				return uTLSConn, nil
-			},
-		}).
-		SetProxy(proxy).
+	return assetdelivery.Locations{loc}
+}
+
+// objectKey returns the S3 key that the contents of item at loc are stored under: the etag of loc for the latest
+// version, or the asset ID and version when crawling versions, so that every version is kept. When storing all
+// formats, the format is added to the key of a version so that every format is kept too.
+func objectKey(item assetdelivery.AssetDescription, loc assetdelivery.Location, allFormats bool) string {
+	if item.Version != 0 {
+		if allFormats {
+			return fmt.Sprintf("%d/%d.%s.gz", item.AssetID, item.Version, loc.AssetFormat)
+		}
+		return fmt.Sprintf("%d/%d.gz", item.AssetID, item.Version)
+	}
+
+	return loc.Etag() + ".gz"
+}
+
+// store downloads the contents of item at loc with downloader, and uploads them gzipped with gz to S3 under key.
+func store(eCtx context.Context, downloader *http.Client, uploader *manager.Uploader, gz *gzip.Writer, item assetdelivery.AssetDescription, loc assetdelivery.Location, key string) error {
+	ctx, cancel := context.WithTimeout(eCtx, time.Second*5)
+	defer cancel()
+
+	logger := logrus.WithFields(logrus.Fields{"item": item, "format": loc.AssetFormat})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc.Location, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	logger.Trace("initializing download")
+	resp, err := downloader.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get asset: %w", err)
+	}
+	defer resp.Body.Close()
+	logger.Trace("initialized download")
+
+	// an error page would otherwise be stored as the asset
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get asset: unexpected status %s", resp.Status)
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		gz.Reset(pw)
+		defer gz.Reset(nil)
+		if _, err := io.Copy(gz, resp.Body); err != nil {
+			logger.WithError(err).Error("couldn't stream response body")
+			pw.CloseWithError(err)
+			return
+		}
+		if err := gz.Close(); err != nil {
+			logger.WithError(err).Error("couldn't close/flush gzip writer")
+		}
+
+		pw.Close()
+	}()
+
+	logger.Trace("initializing s3 upload")
+	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   pr,
+		Metadata: map[string]string{
+			"asset-type":    item.AssetTypeID.String(),
+			"asset-version": fmt.Sprint(item.Version),
+			"asset-format":  loc.AssetFormat,
+		},
+	})
+	logger.Trace("finished s3 upload")
+	// unblock the copy if the upload stopped reading, and wait for it to let go of gz
+	pr.Close()
+	<-done
+	if err != nil {
+		return fmt.Errorf("couldn't upload to s3: %w", err)
+	}
+
+	return nil
+}
+
+// newDownloader returns the client that downloads assets from the CDN, through the proxy at proxy if it isn't empty,
+// which may be any proxy that transport.NewDialer supports. The CDN doesn't look at ClientHellos, so the downloads
+// handshake with crypto/tls rather than a spoofed ClientHello. Either way, HTTP_PROXY and HTTPS_PROXY are ignored.
+func newDownloader(proxy string) (*http.Client, error) {
+	dial := (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	if proxy != "" {
+		dialer, err := transport.NewDialer(proxy)
+		if err != nil {
+			return nil, err
+		}
+		dial = dialer.DialContext
+	}
+
+	return &http.Client{Transport: &http.Transport{
+		DialContext:         dial,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}}, nil
+}
+
+// network is how an indexer reaches the Asset Delivery API, reported on in the client.Response.
+type network struct {
+	tracker *transport.Tracker
+	// pool is nil without proxies
+	pool *proxypool.Pool
+}
+
+// report fills in the TLS profile and proxy stats of resp.
+func (n *network) report(resp *client.Response) {
+	resp.Profiles = n.tracker.Stats()
+
+	if n.pool != nil {
+		resp.Proxies = n.pool.Stats()
+	}
+}
+
+// newClientWithOptions returns a resty client with browser-like headers and a ClientHello picked by strategy,
+// tunneling through the pool of proxies if there are any. The network counts the outcomes of its requests.
+func newClientWithOptions(proxies []string, opts proxypool.Options, strategy transport.Strategy) (*resty.Client, *network, error) {
+	n := &network{tracker: &transport.Tracker{Strategy: strategy}}
+	newTransport := func(proxy *url.URL) (http.RoundTripper, error) {
+		var proxyURL string
+		if proxy != nil {
+			proxyURL = proxy.String()
+		}
+		dialer, err := transport.NewDialer(proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		n.tracker.Watch(dialer)
+		return transport.NewRoundTripper(dialer), nil
+	}
+
+	var rt http.RoundTripper
+	if len(proxies) > 0 {
+		pool, err := proxypool.New(proxies, opts, newTransport)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.pool, rt = pool, pool
+	} else {
+		var err error
+		if rt, err = newTransport(nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	n.tracker.Transport = rt
+
+	// retries are left to the assetdelivery.Client, which backs off between them
+	return resty.New().
+		SetTransport(n.tracker).
 		SetHeaders(map[string]string{
 			"Accept-Encoding":           "gzip, deflate, br",
 			"Pragma":                    "No-Cache",
@@ -179,68 +344,187 @@ func newClientWithOptions(proxy string) (*resty.Client, error) {
 			// "User-Agent":                browser.UserAgent,
 			"Accept":        "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp, image/apng,*/*;q=0.8",
 			"Cache-Control": "No-Cache",
-		}), nil
+		}), n, nil
+}
+
+// newIndexer returns an Asset Delivery API client with the TLS profiles of in, going through the proxies in
+// INDEXER_PROXY, if any, as a list or templates split by proxypool.Split, and authenticated by rotating between
+// the accounts in INDEXER_CREDENTIALS, if any, as parsed by assetdelivery.ParseCredentials.
+// INDEXER_PROXY_SESSIONS sets the number of sessions that each proxy template is expanded into.
+func newIndexer(in client.Request) (*assetdelivery.Client, *network, error) {
+	profiles, err := transport.ParseProfiles(in.TLSProfiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	strategy, err := transport.ParseStrategy(in.TLSStrategy, profiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rotation, err := proxypool.ParseRotation(in.ProxyRotation)
+	if err != nil {
+		return nil, nil, err
+	}
+	poolOpts := proxypool.Options{Rotation: rotation, Rate: in.ProxyRate}
+	if env := os.Getenv("INDEXER_PROXY_SESSIONS"); env != "" {
+		if poolOpts.Sessions, err = strconv.Atoi(env); err != nil {
+			return nil, nil, fmt.Errorf("parse INDEXER_PROXY_SESSIONS: %w", err)
+		}
+	}
+
+	restyClient, n, err := newClientWithOptions(proxypool.Split(os.Getenv("INDEXER_PROXY")), poolOpts, strategy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var opts []assetdelivery.Option
+	if env := os.Getenv("INDEXER_CREDENTIALS"); env != "" {
+		creds, err := assetdelivery.ParseCredentials(env)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse INDEXER_CREDENTIALS: %w", err)
+		}
+		logrus.WithField("accounts", creds).Debug("authenticating indexer")
+		opts = append(opts, assetdelivery.WithCredentials(assetdelivery.NewRotation(creds...)))
+	}
+
+	return assetdelivery.NewClient(restyClient, opts...), n, nil
+}
+
+// sample looks up the IDs of a sampling request and tallies the asset types found, without downloading anything.
+func sample(in client.Request) (*client.Response, error) {
+	indexer, n, err := newIndexer(in)
+	if err != nil {
+		return nil, err
+	}
+
+	t0 := time.Now()
+	tally, err := sampling.Run(context.Background(), indexer, in.Ranges, rate.NewLimiter(rate.Every(time.Second), 1))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &client.Response{
+		StatusCode:           http.StatusOK,
+		Total:                int(tally.Sampled + tally.Failed),
+		DurationMilliseconds: int(time.Since(t0).Milliseconds()),
+		Samples:              &tally,
+	}
+	n.report(resp)
+
+	return resp, nil
 }
 
-func indexLoop(eCtx context.Context, eg *errgroup.Group, rngs ranges.Ranges, items chan<- assetdelivery.AssetDescription, rt time.Duration) error {
+// indexLoop looks up the IDs of in.Ranges in batches, and sends the assets selected by in to items.
+// With in.Versions set, it sends every version of the selected assets instead, walking up to versionWorkers
+// assets at a time under the same rate limit as the batches.
+// It gives up once more than 30% of the batch requests have been blocked or throttled, counting from the
+// minBatchesToGiveUp-th result: batches finish in any order, and a couple of early failures would otherwise
+// be enough to give up on the whole range.
+func indexLoop(eCtx context.Context, indexer *assetdelivery.Client, in client.Request, items chan<- assetdelivery.AssetDescription, results *outcomes, rt time.Duration) (err error) {
 	defer close(items)
-	proxy := os.Getenv("INDEXER_PROXY")
 
-	restyClient, err := newClientWithOptions(proxy)
+	match, err := in.Predicate()
 	if err != nil {
 		return err
 	}
 
-	client := assetdelivery.NewClient(restyClient)
-	limiter := rate.NewLimiter(rate.Every(rt), 1)
+	// stop the batches still in flight when giving up
+	ctx, cancel := context.WithCancel(eCtx)
+	defer cancel()
 
-	var wg sync.WaitGroup
-	var count atomic.Int64
-	var failedCount atomic.Int64
+	limiter := rate.NewLimiter(rate.Every(rt), 1)
+	batches := indexer.BatchAll(ctx, in.Ranges, &assetdelivery.BatchAllOptions{
+		BatchOptions: assetdelivery.BatchOptions{SkipSigningScripts: true},
+		Order:        in.Order,
+		Workers:      indexWorkers,
+		Limiter:      limiter,
+	})
 
-	for {
-		rng := rngs.Pop(256)
-		ids := rng.AsIntSlice()
-		if len(ids) == 0 {
-			break
+	// the selected assets are handed to the walkers, so that assets with many versions don't hold up the batches
+	assets := make(chan assetdelivery.AssetDescription)
+	var walkers errgroup.Group
+	if in.Versions {
+		for i := 0; i < versionWorkers; i++ {
+			walkers.Go(func() error { return walkVersions(ctx, indexer, assets, items, results, limiter) })
 		}
-		wg.Add(1)
-		count.Inc()
-
-		if err := limiter.Wait(eCtx); err != nil {
-			return eCtx.Err()
+	}
+	defer func() {
+		if err != nil {
+			cancel()
 		}
-
-		eg.Go(func() error {
-			defer wg.Done()
-			logrus.WithField("range", rng).Trace("making batch request")
-			resp, err := client.Batch(eCtx, ids, &assetdelivery.BatchOptions{SkipSigningScripts: true})
-			logrus.WithField("range", rng).Trace("got batch request")
-			if err != nil {
-				var rErr assetdelivery.ErrorsResponse
-				if errors.As(err, &rErr) {
-					if rErr.StatusCode == http.StatusForbidden || rErr.StatusCode == http.StatusTooManyRequests {
-						if failedCount.Inc() > count.Load()/3 {
-							return errors.New("exceeded 30% of batch requests getting 403/429's")
-						}
-					}
+		close(assets)
+		if walkErr := walkers.Wait(); err == nil {
+			err = walkErr
+		}
+	}()
+
+	var count, failedCount int
+	for batch := range batches {
+		count++
+		results.addRetries(batch.Stats.Retries)
+		logrus.WithField("range", batch.Ranges).Trace("got batch request")
+
+		if batch.Err != nil {
+			results.addRanges(&results.failed, batch.Ranges)
+			if errors.Is(batch.Err, assetdelivery.ErrForbidden) || errors.Is(batch.Err, assetdelivery.ErrRateLimited) {
+				failedCount++
+				if count >= minBatchesToGiveUp && failedCount > count/3 {
+					return fmt.Errorf("exceeded 30%% of batch requests getting 403/429's: %w", batch.Err)
 				}
-				logrus.WithError(err).Error("skipping")
-				return nil
 			}
+			logrus.WithError(batch.Err).Error("skipping")
+			continue
+		}
+
+		results.addRanges(&results.indexed, batch.Ranges)
+		for _, item := range batch.Descriptions.DiscardErrored() {
+			if !match.Match(item) {
+				continue
+			}
+			results.add(&results.found, item.AssetID)
 
-			for _, item := range resp.DiscardErrored().FilterByAssetType(10) {
-				select {
-				case <-eCtx.Done():
-					return eCtx.Err()
-				case items <- item:
-				}
+			out := items
+			if in.Versions {
+				out = assets
+			}
+			select {
+			case <-ctx.Done():
+				return eCtx.Err()
+			case out <- item:
 			}
-			return nil
+		}
+	}
+
+	return eCtx.Err()
+}
+
+// walkVersions sends every version of the assets received from assets to items, until assets is closed.
+func walkVersions(ctx context.Context, indexer *assetdelivery.Client, assets <-chan assetdelivery.AssetDescription, items chan<- assetdelivery.AssetDescription, results *outcomes, limiter *rate.Limiter) error {
+	for item := range assets {
+		var stats assetdelivery.CallStats
+		versions, err := indexer.Versions(ctx, item.AssetID, &assetdelivery.BatchAllOptions{
+			BatchOptions: assetdelivery.BatchOptions{SkipSigningScripts: true, Stats: &stats},
+			Limiter:      limiter,
 		})
+		results.addRetries(stats.Retries)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			results.add(&results.failed, item.AssetID)
+			logrus.WithError(err).WithField("asset_id", item.AssetID).Error("couldn't list versions, skipping")
+			continue
+		}
+
+		for _, version := range versions.DiscardErrored() {
+			results.addVersion(version)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case items <- version:
+			}
+		}
 	}
-	logrus.Trace("initialized all batch fetches")
-	wg.Wait()
 
 	return nil
 }
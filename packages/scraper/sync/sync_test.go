@@ -0,0 +1,251 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/assetdelivery/fakeassetdelivery"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/client"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/proxypool"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/ranges"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport/fakeproxy"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport/profilestats"
+	"golang.org/x/sync/errgroup"
+)
+
+func runIndexLoop(s *fakeassetdelivery.Server, in client.Request) ([]int64, *outcomes, error) {
+	items := make(chan assetdelivery.AssetDescription, 100_000)
+	var results outcomes
+
+	eg, eCtx := errgroup.WithContext(context.Background())
+	eg.Go(func() error {
+		return indexLoop(eCtx, s.AssetDeliveryClient(), in, items, &results, time.Millisecond)
+	})
+	err := eg.Wait()
+
+	var ids []int64
+	for item := range items {
+		ids = append(ids, item.AssetID)
+	}
+
+	return ids, &results, err
+}
+
+func TestIndexLoop(t *testing.T) {
+	rng, err := ranges.NewRange(1, 3000)
+	require.NoError(t, err)
+	rngs := ranges.Ranges{rng}
+
+	t.Run("selects matching assets", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer(fakeassetdelivery.WithBOM())
+		defer s.Close()
+
+		in := client.Request{
+			Ranges:       rngs,
+			AssetTypes:   []assetdelivery.AssetType{assetdelivery.Decal, assetdelivery.Audio},
+			SkipArchived: true,
+		}
+		ids, results, err := runIndexLoop(s, in)
+		require.NoError(t, err)
+
+		match, err := in.Predicate()
+		require.NoError(t, err)
+		var expected []int64
+		rng.Each(func(id int64) bool {
+			if match.Match(fakeassetdelivery.Synthetic(id)) {
+				expected = append(expected, id)
+			}
+			return true
+		})
+
+		assert.ElementsMatch(t, expected, ids)
+		assert.Equal(t, rngs, results.indexed.Ranges())
+		assert.Equal(t, int64(len(expected)), results.found.Len())
+		assert.Zero(t, results.failed.Len())
+		assert.Equal(t, 12, s.Requests())
+	})
+
+	t.Run("records failed batches", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		s.Fail(http.StatusGatewayTimeout, 2)
+
+		// whole batches only, since any two of them may fail
+		full, err := ranges.NewRange(1, 12*256)
+		require.NoError(t, err)
+		_, results, err := runIndexLoop(s, client.Request{Ranges: ranges.Ranges{full}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2*256), results.failed.Len())
+		assert.Equal(t, full.Len()-2*256, results.indexed.Len())
+	})
+
+	t.Run("gives up when blocked", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		s.Fail(http.StatusForbidden, 12)
+
+		_, _, err := runIndexLoop(s, client.Request{Ranges: rngs})
+		assert.Error(t, err)
+	})
+
+	t.Run("waits for a few batches before giving up", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+		// 2 blocked out of the first 8 results is under a third, wherever they fall
+		s.Fail(http.StatusForbidden, 2)
+
+		_, results, err := runIndexLoop(s, client.Request{Ranges: rngs})
+		require.NoError(t, err)
+		assert.Equal(t, 12, s.Requests(), "every batch was requested")
+		assert.NotZero(t, results.failed.Len())
+		assert.Equal(t, rng.Len(), results.failed.Len()+results.indexed.Len())
+	})
+	t.Run("walks versions", func(t *testing.T) {
+		s := fakeassetdelivery.NewServer()
+		defer s.Close()
+
+		few, err := ranges.NewRange(1, 100)
+		require.NoError(t, err)
+		ids, results, err := runIndexLoop(s, client.Request{Ranges: ranges.Ranges{few}, Versions: true})
+		require.NoError(t, err)
+
+		var expected []client.AssetVersion
+		few.Each(func(id int64) bool {
+			if fakeassetdelivery.Synthetic(id).AssetTypeID == assetdelivery.Model {
+				for v := 1; v <= fakeassetdelivery.SyntheticVersions(id); v++ {
+					expected = append(expected, client.AssetVersion{AssetID: id, Version: v})
+				}
+			}
+			return true
+		})
+
+		assert.Len(t, ids, len(expected))
+		require.Len(t, results.versions, len(expected))
+		for i := range results.versions {
+			assert.NotEmpty(t, results.versions[i].Hash)
+			results.versions[i].Hash = ""
+		}
+		assert.ElementsMatch(t, expected, results.versions)
+	})
+}
+
+func TestLocationsToStore(t *testing.T) {
+	item := assetdelivery.AssetDescription{
+		AssetID: 42,
+		Locations: assetdelivery.Locations{
+			{AssetFormat: "source", Location: "https://c0.rbxcdn.com/aaa"},
+			{AssetFormat: "mesh", Location: "https://c1.rbxcdn.com/bbb"},
+		},
+	}
+
+	type testCase struct {
+		in       client.Request
+		version  int
+		expected []string
+	}
+
+	cases := []testCase{
+		{in: client.Request{}, expected: []string{"aaa.gz"}},
+		{in: client.Request{Formats: []string{"mesh", "source"}}, expected: []string{"bbb.gz"}},
+		{in: client.Request{Formats: []string{"png"}}, expected: []string{"aaa.gz"}},
+		{in: client.Request{AllFormats: true}, expected: []string{"aaa.gz", "bbb.gz"}},
+		{in: client.Request{Formats: []string{"mesh"}}, version: 3, expected: []string{"42/3.gz"}},
+		{in: client.Request{AllFormats: true}, version: 3, expected: []string{"42/3.source.gz", "42/3.mesh.gz"}},
+	}
+
+	for _, c := range cases {
+		item := item
+		item.Version = c.version
+
+		var keys []string
+		for _, loc := range locationsToStore(c.in, item) {
+			keys = append(keys, objectKey(item, loc, c.in.AllFormats))
+		}
+		assert.Equal(t, c.expected, keys, "%+v", c.in)
+	}
+
+	assert.Empty(t, locationsToStore(client.Request{}, assetdelivery.AssetDescription{}))
+}
+
+func TestNewClientWithOptions(t *testing.T) {
+	// without the server's certificate, every handshake fails
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	defer s.Close()
+
+	restyClient, n, err := newClientWithOptions(nil, proxypool.Options{}, transport.Fixed(transport.DefaultProfile))
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err := restyClient.R().Get(s.URL)
+		require.Error(t, err)
+	}
+
+	var resp client.Response
+	n.report(&resp)
+	assert.Equal(t, map[string]profilestats.Stats{transport.DefaultProfile.Name: {Requests: 2, Errors: 2}}, resp.Profiles)
+}
+
+func TestStore(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	// nothing is uploaded, so no uploader is needed
+	loc := assetdelivery.Location{AssetFormat: "source", Location: s.URL + "/cdn/42"}
+	err := store(context.Background(), s.Client(), nil, gzip.NewWriter(io.Discard), assetdelivery.AssetDescription{AssetID: 42}, loc, "42.gz")
+	assert.ErrorContains(t, err, "404 Not Found")
+}
+
+func TestNewDownloader(t *testing.T) {
+	s := fakeassetdelivery.NewServer()
+	defer s.Close()
+	addr := s.Listener.Addr().String()
+
+	download := func(t *testing.T, downloader *http.Client) {
+		resp, err := downloader.Get(s.URL + "/cdn/42")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, fakeassetdelivery.Content(42), body)
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		downloader, err := newDownloader("")
+		require.NoError(t, err)
+		download(t, downloader)
+		assert.Nil(t, downloader.Transport.(*http.Transport).Proxy, "not through HTTP_PROXY or HTTPS_PROXY")
+	})
+
+	type testCase struct {
+		name  string
+		proxy func() *fakeproxy.Proxy
+	}
+
+	for _, tc := range []testCase{
+		{name: "http", proxy: fakeproxy.NewHTTP},
+		{name: "socks5", proxy: func() *fakeproxy.Proxy { return fakeproxy.NewSOCKS5("cdn", "hunter2") }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			proxy := tc.proxy()
+			defer proxy.Close()
+
+			downloader, err := newDownloader(proxy.URL.String())
+			require.NoError(t, err)
+			download(t, downloader)
+			assert.Equal(t, []string{addr}, proxy.Targets())
+		})
+	}
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := newDownloader("ftp://localhost:21")
+		assert.Error(t, err)
+	})
+}
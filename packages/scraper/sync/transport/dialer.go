@@ -0,0 +1,313 @@
+// Package transport dials the connections used to reach the Asset Delivery API, with a ClientHello that
+// looks like a browser's rather than Go's, even through a proxy.
+package transport
+
+import (
+	"bufio"
+	"context"
+	stdtls "crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tls "github.com/refraction-networking/utls"
+	"golang.org/x/net/proxy"
+)
+
+// Dialer dials TCP and TLS connections, tunneling through a proxy if one is set: an HTTP or HTTPS proxy
+// with CONNECT, or a SOCKS5 proxy. Since it opens the tunnel itself, the proxy relays its spoofed ClientHello
+// untouched; an http.Transport with a Proxy would instead open the tunnel and handshake with its own crypto/tls
+// ClientHello.
+type Dialer struct {
+	// Proxy is the URL of the proxy to tunnel through, or nil to connect directly. Its scheme is one of
+	// http, https, socks5, which resolves host names before asking the proxy for their address, or socks5h,
+	// which leaves them for the proxy to resolve. Its user info, if any, is sent as Basic Proxy-Authorization
+	// or as the SOCKS5 username and password.
+	Proxy *url.URL
+	// ProxyTLSConfig is the TLS configuration for an https proxy. Its ServerName defaults to the proxy's host.
+	ProxyTLSConfig *stdtls.Config
+	// HelloID is the ClientHello to send when there is no Strategy. It defaults to tls.HelloRandomizedALPN.
+	HelloID tls.ClientHelloID
+	// Strategy, if set, picks the profile of every TLS connection instead of HelloID.
+	Strategy Strategy
+	// OnHandshakeError, if set, is called with the profile of every TLS handshake that fails, since no request
+	// is made on the connection to tell how the profile fared. Handshakes cut short by their context don't count.
+	OnHandshakeError func(p Profile, err error)
+	// Config is the TLS configuration, e.g. for RootCAs. Its ServerName defaults to the dialed host.
+	Config *tls.Config
+	// NetDialer dials the TCP connections to the proxy or server.
+	NetDialer net.Dialer
+}
+
+// NewDialer returns a Dialer through the proxy at proxyURL, or a direct one if proxyURL is empty.
+func NewDialer(proxyURL string) (*Dialer, error) {
+	d := &Dialer{NetDialer: net.Dialer{Timeout: 30 * time.Second}}
+	if proxyURL == "" {
+		return d, nil
+	}
+
+	proxy, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse proxy URL: %w", err)
+	}
+	switch proxy.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+	}
+	d.Proxy = proxy
+
+	return d, nil
+}
+
+// Transport returns an http.Transport that dials with d. It has no Proxy of its own, since d tunnels.
+// It only speaks HTTP/1.1, even over connections that negotiated h2; see RoundTripper for both.
+func (d *Dialer) Transport() *http.Transport {
+	return &http.Transport{
+		DialContext:         d.DialContext,
+		DialTLSContext:      d.DialTLSContext,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
+// DialContext connects to addr, through the proxy if there is one.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.Proxy == nil {
+		return d.NetDialer.DialContext(ctx, network, addr)
+	}
+	if d.Proxy.Scheme == "socks5" || d.Proxy.Scheme == "socks5h" {
+		return d.dialSOCKS5(ctx, network, addr)
+	}
+
+	conn, err := d.NetDialer.DialContext(ctx, network, proxyAddr(d.Proxy))
+	if err != nil {
+		return nil, fmt.Errorf("dial proxy: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if d.Proxy.Scheme == "https" {
+		config := &stdtls.Config{}
+		if d.ProxyTLSConfig != nil {
+			config = d.ProxyTLSConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName = d.Proxy.Hostname()
+		}
+
+		tlsConn := stdtls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("tls handshake with proxy: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	tunnel, err := connect(conn, d.Proxy, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tunnel, nil
+}
+
+// dialSOCKS5 connects to addr through the SOCKS5 proxy, resolving its host first unless the scheme is socks5h.
+func (d *Dialer) dialSOCKS5(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.Proxy.Scheme == "socks5" {
+		resolved, err := d.resolve(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		addr = resolved
+	}
+
+	var auth *proxy.Auth
+	if d.Proxy.User != nil {
+		password, _ := d.Proxy.User.Password()
+		auth = &proxy.Auth{User: d.Proxy.User.Username(), Password: password}
+	}
+
+	socks, err := proxy.SOCKS5("tcp", proxyAddr(d.Proxy), auth, &d.NetDialer)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := socks.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial through SOCKS5 proxy: %w", err)
+	}
+
+	return conn, nil
+}
+
+// resolve returns addr with its host resolved to an IP address, preferring IPv4 since every proxy can reach it.
+func (d *Dialer) resolve(ctx context.Context, addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return addr, nil
+	}
+
+	resolver := d.NetDialer.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ips, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+
+	ip := ips[0].IP
+	for _, candidate := range ips {
+		if candidate.IP.To4() != nil {
+			ip = candidate.IP
+			break
+		}
+	}
+
+	return net.JoinHostPort(ip.String(), port), nil
+}
+
+// DialTLSContext connects to addr like DialContext, then handshakes with the ClientHello of the profile
+// picked by d.Strategy, or of d.HelloID. The connection is a *Conn, which tells the profile it was made with.
+func (d *Dialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{}
+	if d.Config != nil {
+		config = d.Config.Clone()
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		config.ServerName = host
+	}
+
+	profile := d.profile()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	uConn := tls.UClient(conn, config, profile.HelloID)
+	if profile.Spec != nil {
+		if err := uConn.ApplyPreset(profile.Spec()); err != nil {
+			conn.Close()
+			return nil, d.handshakeError(ctx, profile, fmt.Errorf("apply TLS profile %s: %w", profile.Name, err))
+		}
+	}
+	if err := uConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, d.handshakeError(ctx, profile, fmt.Errorf("tls handshake with %s as %s: %w", addr, profile.Name, err))
+	}
+
+	return &Conn{UConn: uConn, Profile: profile}, nil
+}
+
+// handshakeError reports err, from a handshake with profile p, to d.OnHandshakeError, and returns it.
+func (d *Dialer) handshakeError(ctx context.Context, p Profile, err error) error {
+	if d.OnHandshakeError != nil && ctx.Err() == nil {
+		d.OnHandshakeError(p, err)
+	}
+
+	return err
+}
+
+// profile returns the profile for a new TLS connection.
+func (d *Dialer) profile() Profile {
+	if d.Strategy != nil {
+		return d.Strategy.Next()
+	}
+	if d.HelloID == (tls.ClientHelloID{}) {
+		return DefaultProfile
+	}
+
+	return Profile{Name: d.HelloID.Str(), HelloID: d.HelloID}
+}
+
+// Conn is a TLS connection dialed by a Dialer.
+type Conn struct {
+	*tls.UConn
+	// Profile is the profile the connection handshook with.
+	Profile Profile
+}
+
+// connect asks the proxy on conn to open a tunnel to addr, and returns the tunnel.
+func connect(conn net.Conn, proxy *url.URL, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("send CONNECT: %w", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("read CONNECT response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", addr, resp.Status)
+	}
+
+	// the server doesn't speak before the client does, but keep anything the proxy sent early
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+
+	return conn, nil
+}
+
+// proxyAddr returns the host:port of proxy, defaulting the port by its scheme.
+func proxyAddr(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+
+	port := "80"
+	switch proxy.Scheme {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// bufferedConn is a net.Conn whose reads start with what was already buffered from it.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,289 @@
+package transport
+
+import (
+	"context"
+	stdtls "crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport/fakeproxy"
+)
+
+// helloServer is a local HTTPS server that records the ClientHello of every connection made to it.
+type helloServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	hellos [][]byte
+}
+
+func newHelloServer(t *testing.T) *helloServer {
+	return newHelloServerWithHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+}
+
+func newHelloServerWithHandler(t *testing.T, handler http.Handler) *helloServer {
+	s := &helloServer{}
+	s.Server = newUnstartedHelloServer(s, handler)
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// newUnstartedHelloServer returns the server of s, recording the ClientHellos it receives to s.
+func newUnstartedHelloServer(s *helloServer, handler http.Handler) *httptest.Server {
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = &recordingListener{Listener: server.Listener, s: s}
+	return server
+}
+
+// JA3s returns the JA3 fingerprints of the ClientHellos received so far.
+func (s *helloServer) JA3s(t *testing.T) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var fingerprints []string
+	for _, record := range s.hellos {
+		// skip the record header, since a ClientHello fits in one record
+		require.Greater(t, len(record), 5)
+		fingerprint, err := ja3(record[5:])
+		require.NoError(t, err)
+		fingerprints = append(fingerprints, fingerprint)
+	}
+
+	return fingerprints
+}
+
+type recordingListener struct {
+	net.Listener
+	s *helloServer
+}
+
+func (l *recordingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &recordingConn{Conn: conn, s: l.s}, nil
+}
+
+// recordingConn records the first TLS record read from it, which is the ClientHello.
+type recordingConn struct {
+	net.Conn
+	s    *helloServer
+	buf  []byte
+	done bool
+}
+
+func (c *recordingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if !c.done {
+		c.buf = append(c.buf, b[:n]...)
+		if len(c.buf) >= 5 {
+			if length := 5 + (int(c.buf[3])<<8 | int(c.buf[4])); len(c.buf) >= length {
+				c.done = true
+				c.s.mu.Lock()
+				c.s.hellos = append(c.s.hellos, c.buf[:length])
+				c.s.mu.Unlock()
+			}
+		}
+	}
+
+	return n, err
+}
+
+// profileJA3 returns the JA3 fingerprint of the ClientHello that uTLS builds for id.
+func profileJA3(t *testing.T, id tls.ClientHelloID) string {
+	return customJA3(t, Profile{HelloID: id})
+}
+
+// customJA3 returns the JA3 fingerprint of the ClientHello that uTLS builds for p, which may have a Spec.
+func customJA3(t *testing.T, p Profile) string {
+	uConn := tls.UClient(nil, &tls.Config{ServerName: "example.com"}, p.HelloID)
+	if p.Spec != nil {
+		require.NoError(t, uConn.ApplyPreset(p.Spec()))
+	}
+	require.NoError(t, uConn.BuildHandshakeState())
+
+	fingerprint, err := ja3(uConn.HandshakeState.Hello.Raw)
+	require.NoError(t, err)
+
+	return fingerprint
+}
+
+func TestDialer(t *testing.T) {
+	server := newHelloServer(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	get := func(t *testing.T, rt http.RoundTripper) {
+		resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+	}
+
+	expected := profileJA3(t, tls.HelloChrome_83)
+
+	t.Run("through a CONNECT proxy", func(t *testing.T) {
+		proxy := fakeproxy.NewHTTP()
+		defer proxy.Close()
+		proxyURL := *proxy.URL
+		proxyURL.User = url.UserPassword("user-session-1", "hunter2")
+
+		d := &Dialer{Proxy: &proxyURL, HelloID: tls.HelloChrome_83, Config: &tls.Config{RootCAs: roots}}
+		before := len(server.JA3s(t))
+		get(t, d.Transport())
+
+		fingerprints := server.JA3s(t)[before:]
+		require.Len(t, fingerprints, 1)
+		assert.Equal(t, expected, fingerprints[0])
+
+		assert.Equal(t, []string{server.Listener.Addr().String()}, proxy.Targets())
+		assert.Equal(t, []string{"user-session-1:hunter2"}, proxy.Auths())
+	})
+
+	t.Run("through an HTTPS proxy", func(t *testing.T) {
+		proxy := fakeproxy.NewHTTPS()
+		defer proxy.Close()
+		proxyRoots := x509.NewCertPool()
+		proxyRoots.AddCert(proxy.Certificate())
+
+		d, err := NewDialer(proxy.URL.String())
+		require.NoError(t, err)
+		d.HelloID = tls.HelloChrome_83
+		d.Config = &tls.Config{RootCAs: roots}
+		d.ProxyTLSConfig = &stdtls.Config{RootCAs: proxyRoots}
+		before := len(server.JA3s(t))
+		get(t, d.Transport())
+
+		fingerprints := server.JA3s(t)[before:]
+		require.Len(t, fingerprints, 1)
+		assert.Equal(t, expected, fingerprints[0], "the ClientHello is relayed untouched inside the proxy's TLS")
+		assert.Equal(t, []string{server.Listener.Addr().String()}, proxy.Targets())
+	})
+
+	t.Run("refuses an HTTPS proxy it doesn't trust", func(t *testing.T) {
+		proxy := fakeproxy.NewHTTPS()
+		defer proxy.Close()
+
+		d, err := NewDialer(proxy.URL.String())
+		require.NoError(t, err)
+		_, err = d.DialContext(context.Background(), "tcp", server.Listener.Addr().String())
+		assert.ErrorContains(t, err, "tls handshake with proxy")
+		assert.Empty(t, proxy.Targets())
+	})
+
+	t.Run("through a SOCKS5 proxy", func(t *testing.T) {
+		_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		require.NoError(t, err)
+		// the test certificate is for example.com, and localhost resolves to the server
+		addr := net.JoinHostPort("localhost", port)
+		config := &tls.Config{RootCAs: roots, ServerName: "example.com"}
+
+		type testCase struct {
+			scheme string
+			target string
+		}
+
+		for _, tc := range []testCase{
+			{scheme: "socks5", target: net.JoinHostPort("127.0.0.1", port)},
+			{scheme: "socks5h", target: addr},
+		} {
+			t.Run(tc.scheme, func(t *testing.T) {
+				proxy := fakeproxy.NewSOCKS5("user-session-2", "hunter2")
+				defer proxy.Close()
+				proxyURL := *proxy.URL
+				proxyURL.Scheme = tc.scheme
+
+				d, err := NewDialer(proxyURL.String())
+				require.NoError(t, err)
+				d.HelloID = tls.HelloChrome_83
+				d.Config = config
+
+				before := len(server.JA3s(t))
+				conn, err := d.DialTLSContext(context.Background(), "tcp", addr)
+				require.NoError(t, err)
+				conn.Close()
+
+				fingerprints := server.JA3s(t)[before:]
+				require.Len(t, fingerprints, 1)
+				assert.Equal(t, expected, fingerprints[0])
+				assert.Equal(t, []string{tc.target}, proxy.Targets(), "only socks5h leaves the host name to the proxy")
+				assert.Equal(t, []string{"user-session-2:hunter2"}, proxy.Auths())
+			})
+		}
+
+		t.Run("wrong password", func(t *testing.T) {
+			proxy := fakeproxy.NewSOCKS5("user", "hunter2")
+			defer proxy.Close()
+			proxyURL := *proxy.URL
+			proxyURL.User = url.UserPassword("user", "*******")
+
+			d := &Dialer{Proxy: &proxyURL}
+			_, err := d.DialContext(context.Background(), "tcp", addr)
+			assert.Error(t, err)
+			assert.Empty(t, proxy.Targets())
+		})
+	})
+
+	t.Run("direct", func(t *testing.T) {
+		d := &Dialer{HelloID: tls.HelloChrome_83, Config: &tls.Config{RootCAs: roots}}
+		before := len(server.JA3s(t))
+		get(t, d.Transport())
+
+		fingerprints := server.JA3s(t)[before:]
+		require.Len(t, fingerprints, 1)
+		assert.Equal(t, expected, fingerprints[0])
+	})
+
+	t.Run("net/http handshakes itself through a proxy", func(t *testing.T) {
+		// what happened before the Dialer: the custom DialTLS is bypassed for proxied requests
+		proxy := fakeproxy.NewHTTP()
+		defer proxy.Close()
+		tr := &http.Transport{
+			Proxy:           http.ProxyURL(proxy.URL),
+			TLSClientConfig: &stdtls.Config{RootCAs: roots},
+			DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return (&Dialer{HelloID: tls.HelloChrome_83, Config: &tls.Config{RootCAs: roots}}).DialTLSContext(ctx, network, addr)
+			},
+		}
+		before := len(server.JA3s(t))
+		get(t, tr)
+
+		fingerprints := server.JA3s(t)[before:]
+		require.Len(t, fingerprints, 1)
+		assert.NotEqual(t, expected, fingerprints[0])
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+		}))
+		defer proxy.Close()
+
+		d, err := NewDialer(proxy.URL)
+		require.NoError(t, err)
+		_, err = d.DialTLSContext(context.Background(), "tcp", server.Listener.Addr().String())
+		assert.ErrorContains(t, err, "407")
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := NewDialer("gopher://localhost:70")
+		assert.Error(t, err)
+	})
+}
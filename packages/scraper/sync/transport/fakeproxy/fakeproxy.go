@@ -0,0 +1,342 @@
+// Package fakeproxy provides in-process stand-ins for the proxies that requests are tunneled through:
+// HTTP and HTTPS proxies that only support CONNECT, and SOCKS5 proxies. They record where they were asked to connect.
+package fakeproxy
+
+import (
+	"bufio"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Proxy is a running stand-in proxy.
+type Proxy struct {
+	// URL is the URL of the proxy, with the credentials it requires, if any.
+	URL *url.URL
+
+	server   *httptest.Server
+	listener net.Listener
+	user     string
+	password string
+
+	mu      sync.Mutex
+	targets []string
+	auths   []string
+	conns   map[net.Conn]bool
+}
+
+// NewHTTP starts an HTTP proxy. The caller should call Close when finished, to shut it down.
+func NewHTTP() *Proxy {
+	p := &Proxy{}
+	p.server = httptest.NewServer(http.HandlerFunc(p.handleConnect))
+	p.URL, _ = url.Parse(p.server.URL)
+
+	return p
+}
+
+// NewHTTPS starts an HTTP proxy that is reached over TLS, with the certificate returned by Certificate.
+// The caller should call Close when finished, to shut it down.
+func NewHTTPS() *Proxy {
+	p := &Proxy{}
+	p.server = httptest.NewTLSServer(http.HandlerFunc(p.handleConnect))
+	p.URL, _ = url.Parse(p.server.URL)
+
+	return p
+}
+
+// NewSOCKS5 starts a SOCKS5 proxy, which requires the given username and password unless user is empty.
+// The caller should call Close when finished, to shut it down.
+func NewSOCKS5(user, password string) *Proxy {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("fakeproxy: failed to listen: %v", err))
+	}
+
+	p := &Proxy{
+		URL:      &url.URL{Scheme: "socks5", Host: l.Addr().String()},
+		listener: l,
+		user:     user,
+		password: password,
+		conns:    make(map[net.Conn]bool),
+	}
+	if user != "" {
+		p.URL.User = url.UserPassword(user, password)
+	}
+	go p.serveSOCKS5()
+
+	return p
+}
+
+// Close shuts the proxy down, closing the tunnels still open.
+func (p *Proxy) Close() {
+	if p.server != nil {
+		p.server.CloseClientConnections()
+		p.server.Close()
+		return
+	}
+
+	p.listener.Close()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for conn := range p.conns {
+		conn.Close()
+	}
+}
+
+// Certificate returns the certificate of an HTTPS proxy.
+func (p *Proxy) Certificate() *x509.Certificate {
+	return p.server.Certificate()
+}
+
+// Targets returns the host:port addresses that the proxy was asked to connect to so far, as they were sent:
+// a SOCKS5 proxy is sent either a host name or an IP address.
+func (p *Proxy) Targets() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.targets...)
+}
+
+// Auths returns the user:password credentials that came with each request to connect, or "" for none.
+func (p *Proxy) Auths() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.auths...)
+}
+
+func (p *Proxy) record(target, auth string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.targets = append(p.targets, target)
+	p.auths = append(p.auths, auth)
+}
+
+func (p *Proxy) handleConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var auth string
+	if header := r.Header.Get("Proxy-Authorization"); strings.HasPrefix(header, "Basic ") {
+		decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Basic "))
+		auth = string(decoded)
+	}
+	p.record(r.Host, auth)
+
+	upstream, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+
+	pipe(conn, buf, upstream)
+}
+
+func (p *Proxy) serveSOCKS5() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		p.mu.Lock()
+		p.conns[conn] = true
+		p.mu.Unlock()
+
+		go func() {
+			defer func() {
+				conn.Close()
+				p.mu.Lock()
+				delete(p.conns, conn)
+				p.mu.Unlock()
+			}()
+			p.handleSOCKS5(conn)
+		}()
+	}
+}
+
+// SOCKS5 constants, from RFC 1928 and RFC 1929.
+const (
+	socksVersion         = 5
+	methodNone           = 0
+	methodPassword       = 2
+	methodNoAcceptable   = 0xff
+	commandConnect       = 1
+	addrIPv4             = 1
+	addrDomain           = 3
+	addrIPv6             = 4
+	replySucceeded       = 0
+	replyHostUnreachable = 4
+	replyNotSupported    = 7
+)
+
+func (p *Proxy) handleSOCKS5(conn net.Conn) {
+	r := bufio.NewReader(conn)
+
+	methods, err := readGreeting(r)
+	if err != nil {
+		return
+	}
+	want := byte(methodNone)
+	if p.user != "" {
+		want = methodPassword
+	}
+	if !methods[want] {
+		conn.Write([]byte{socksVersion, methodNoAcceptable})
+		return
+	}
+	conn.Write([]byte{socksVersion, want})
+
+	var auth string
+	if want == methodPassword {
+		user, password, err := readPassword(r)
+		if err != nil {
+			return
+		}
+		if user != p.user || password != p.password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+		auth = user + ":" + password
+	}
+
+	command, target, err := readRequest(r)
+	if err != nil {
+		return
+	}
+	if command != commandConnect {
+		reply(conn, replyNotSupported)
+		return
+	}
+	p.record(target, auth)
+
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		reply(conn, replyHostUnreachable)
+		return
+	}
+	reply(conn, replySucceeded)
+
+	pipe(conn, r, upstream)
+}
+
+// readGreeting reads the authentication methods offered by a SOCKS5 client.
+func readGreeting(r *bufio.Reader) (map[byte]bool, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != socksVersion {
+		return nil, errors.New("not SOCKS5")
+	}
+
+	offered := make([]byte, header[1])
+	if _, err := io.ReadFull(r, offered); err != nil {
+		return nil, err
+	}
+	methods := make(map[byte]bool, len(offered))
+	for _, m := range offered {
+		methods[m] = true
+	}
+
+	return methods, nil
+}
+
+// readPassword reads the username and password of a SOCKS5 client.
+func readPassword(r *bufio.Reader) (user, password string, err error) {
+	if _, err := r.ReadByte(); err != nil { // subnegotiation version
+		return "", "", err
+	}
+	if user, err = readString(r); err != nil {
+		return "", "", err
+	}
+	if password, err = readString(r); err != nil {
+		return "", "", err
+	}
+
+	return user, password, nil
+}
+
+// readRequest reads the command of a SOCKS5 client and the host:port it applies to.
+func readRequest(r *bufio.Reader) (command byte, target string, err error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, "", err
+	}
+
+	var host string
+	switch header[3] {
+	case addrIPv4, addrIPv6:
+		ip := make(net.IP, 4)
+		if header[3] == addrIPv6 {
+			ip = make(net.IP, 16)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return 0, "", err
+		}
+		host = ip.String()
+	case addrDomain:
+		if host, err = readString(r); err != nil {
+			return 0, "", err
+		}
+	default:
+		return 0, "", errors.New("unknown address type")
+	}
+
+	var port [2]byte
+	if _, err := io.ReadFull(r, port[:]); err != nil {
+		return 0, "", err
+	}
+
+	return header[1], net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))), nil
+}
+
+// readString reads a string prefixed by its length in a byte.
+func readString(r *bufio.Reader) (string, error) {
+	n, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// reply answers a SOCKS5 request with code, and an unspecified bound address.
+func reply(conn net.Conn, code byte) {
+	conn.Write([]byte{socksVersion, code, 0, addrIPv4, 0, 0, 0, 0, 0, 0})
+}
+
+// pipe copies between the client conn, whose reads start with r, and upstream until either side is done.
+func pipe(conn net.Conn, r io.Reader, upstream net.Conn) {
+	go func() {
+		io.Copy(upstream, r)
+		upstream.Close()
+	}()
+	io.Copy(conn, upstream)
+	conn.Close()
+}
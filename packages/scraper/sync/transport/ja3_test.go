@@ -0,0 +1,174 @@
+package transport
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Extensions of the ClientHello that JA3 lists the contents of.
+const (
+	extensionSupportedGroups = 10
+	extensionPointFormats    = 11
+)
+
+var errShortHello = errors.New("ClientHello too short")
+
+// ja3 returns the JA3 fingerprint string of a ClientHello handshake message, without its record header:
+// the version, cipher suites, extensions, supported groups and point formats, with GREASE values dropped.
+func ja3(hello []byte) (string, error) {
+	r := reader(hello)
+	if typ, ok := r.uint8(); !ok || typ != 1 {
+		return "", errors.New("not a ClientHello")
+	}
+	body, ok := r.bytes(3)
+	if !ok {
+		return "", errShortHello
+	}
+
+	r = body
+	version, ok := r.uint16()
+	if !ok || !r.skip(32) {
+		return "", errShortHello
+	}
+	if _, ok := r.bytes(1); !ok { // session ID
+		return "", errShortHello
+	}
+	suites, ok := r.bytes(2)
+	if !ok {
+		return "", errShortHello
+	}
+	if _, ok := r.bytes(1); !ok { // compression methods
+		return "", errShortHello
+	}
+	exts, ok := r.bytes(2)
+	if !ok {
+		return "", errShortHello
+	}
+
+	var extTypes, groups, pointFormats []uint16
+	for len(exts) > 0 {
+		typ, ok := exts.uint16()
+		if !ok {
+			return "", errShortHello
+		}
+		data, ok := exts.bytes(2)
+		if !ok {
+			return "", errShortHello
+		}
+		extTypes = append(extTypes, typ)
+
+		switch typ {
+		case extensionSupportedGroups:
+			list, ok := data.bytes(2)
+			if !ok {
+				return "", errShortHello
+			}
+			groups = list.uint16s()
+		case extensionPointFormats:
+			list, ok := data.bytes(1)
+			if !ok {
+				return "", errShortHello
+			}
+			for _, b := range list {
+				pointFormats = append(pointFormats, uint16(b))
+			}
+		}
+	}
+
+	return strings.Join([]string{
+		fmt.Sprint(version),
+		join(suites.uint16s()),
+		join(extTypes),
+		join(groups),
+		join(pointFormats),
+	}, ","), nil
+}
+
+// isGREASE reports whether v is one of the values reserved by RFC 8701 to keep servers tolerant of unknown ones.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+// join joins the values that aren't GREASE with dashes.
+func join(values []uint16) string {
+	var strs []string
+	for _, v := range values {
+		if !isGREASE(v) {
+			strs = append(strs, fmt.Sprint(v))
+		}
+	}
+
+	return strings.Join(strs, "-")
+}
+
+// reader reads the big-endian fields of a TLS message.
+type reader []byte
+
+func (r *reader) skip(n int) bool {
+	if len(*r) < n {
+		return false
+	}
+	*r = (*r)[n:]
+	return true
+}
+
+func (r *reader) uint8() (uint8, bool) {
+	if len(*r) < 1 {
+		return 0, false
+	}
+	v := (*r)[0]
+	*r = (*r)[1:]
+	return v, true
+}
+
+func (r *reader) uint16() (uint16, bool) {
+	if len(*r) < 2 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint16(*r)
+	*r = (*r)[2:]
+	return v, true
+}
+
+// bytes reads a field prefixed by its length in lenBytes bytes.
+func (r *reader) bytes(lenBytes int) (reader, bool) {
+	if len(*r) < lenBytes {
+		return nil, false
+	}
+	var n int
+	for _, b := range (*r)[:lenBytes] {
+		n = n<<8 | int(b)
+	}
+	*r = (*r)[lenBytes:]
+	if len(*r) < n {
+		return nil, false
+	}
+	v := (*r)[:n]
+	*r = (*r)[n:]
+	return v, true
+}
+
+func (r reader) uint16s() []uint16 {
+	values := make([]uint16, 0, len(r)/2)
+	for len(r) >= 2 {
+		v, _ := r.uint16()
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestGREASE(t *testing.T) {
+	for _, v := range []uint16{0x0a0a, 0x1a1a, 0x8a8a, 0xfafa} {
+		assert.True(t, isGREASE(v), "%#04x", v)
+	}
+	for _, v := range []uint16{0x0a1a, 0x1301, 0x001d, 0x0017} {
+		assert.False(t, isGREASE(v), "%#04x", v)
+	}
+
+	assert.Equal(t, "4865-4866", join([]uint16{0x2a2a, 4865, 0xdada, 4866}))
+}
@@ -0,0 +1,260 @@
+package transport
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+
+	tls "github.com/refraction-networking/utls"
+)
+
+// Profile is a ClientHello to handshake with.
+type Profile struct {
+	// Name identifies the profile in stats.
+	Name    string
+	HelloID tls.ClientHelloID
+	// Spec, if non-nil, builds a custom ClientHello to send instead of HelloID's. It's called for every connection,
+	// since the extensions of a spec hold state and can't be shared between connections.
+	Spec func() *tls.ClientHelloSpec
+}
+
+// CustomProfile returns a Profile sending the ClientHello built by spec. Register it with RegisterProfile
+// to configure it by name.
+func CustomProfile(name string, spec func() *tls.ClientHelloSpec) Profile {
+	return Profile{Name: name, HelloID: tls.HelloCustom, Spec: spec}
+}
+
+var (
+	registeredMu sync.RWMutex
+	// registered are the profiles added with RegisterProfile, by lowercased name.
+	registered = make(map[string]Profile)
+)
+
+// RegisterProfile makes ParseProfile return p for its name, case-insensitively, so that a custom profile can be
+// picked by name like the built-in ones, e.g. in the TLS profiles of a sync request. It's meant to be called
+// from an init function, and panics if the name is already taken.
+func RegisterProfile(p Profile) {
+	name := strings.ToLower(p.Name)
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	if _, ok := profiles[name]; ok {
+		panic(fmt.Sprintf("transport: TLS profile %q registered twice", name))
+	}
+	if _, ok := registered[name]; ok {
+		panic(fmt.Sprintf("transport: TLS profile %q registered twice", name))
+	}
+	registered[name] = p
+}
+
+// profiles are the profiles that ParseProfile knows by name.
+var profiles = map[string]tls.ClientHelloID{
+	"chrome":             tls.HelloChrome_Auto,
+	"chrome-72":          tls.HelloChrome_72,
+	"chrome-83":          tls.HelloChrome_83,
+	"firefox":            tls.HelloFirefox_Auto,
+	"firefox-63":         tls.HelloFirefox_63,
+	"firefox-65":         tls.HelloFirefox_65,
+	"ios":                tls.HelloIOS_Auto,
+	"ios-11":             tls.HelloIOS_11_1,
+	"ios-12":             tls.HelloIOS_12_1,
+	"safari":             tls.HelloIOS_Auto, // utls only has the ClientHello of Safari on iOS
+	"randomized":         tls.HelloRandomized,
+	"randomized-alpn":    tls.HelloRandomizedALPN,
+	"randomized-no-alpn": tls.HelloRandomizedNoALPN,
+	"golang":             tls.HelloGolang,
+}
+
+// DefaultProfile is the profile used when none is configured.
+var DefaultProfile = Profile{Name: "randomized-alpn", HelloID: tls.HelloRandomizedALPN}
+
+// ParseProfile returns the profile with the given name, case-insensitively: a browser such as "chrome",
+// "firefox", "ios" or "safari", optionally with a version such as "chrome-83", or "randomized",
+// "randomized-alpn", "randomized-no-alpn" or "golang", or one added with RegisterProfile.
+func ParseProfile(name string) (Profile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	registeredMu.RLock()
+	p, ok := registered[name]
+	registeredMu.RUnlock()
+	if ok {
+		return p, nil
+	}
+
+	id, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown TLS profile %q", name)
+	}
+
+	return Profile{Name: name, HelloID: id}, nil
+}
+
+// ParseProfiles parses a list of profile names with ParseProfile.
+func ParseProfiles(names []string) ([]Profile, error) {
+	parsed := make([]Profile, 0, len(names))
+	for _, name := range names {
+		p, err := ParseProfile(name)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, p)
+	}
+
+	return parsed, nil
+}
+
+// Outcome is how a request made on a connection fared.
+type Outcome int
+
+const (
+	// Succeeded means the request got a response that wasn't blocked or throttled.
+	Succeeded Outcome = iota
+	// Throttled means the request got a 429.
+	Throttled
+	// Forbidden means the request got a 403.
+	Forbidden
+	// Failed means the request got no response at all.
+	Failed
+)
+
+// Strategy picks the profile of each connection, possibly learning from the outcomes of the requests made with them.
+// Its methods are called concurrently.
+type Strategy interface {
+	// Next returns the profile for a new connection.
+	Next() Profile
+	// Observe records the outcome of a request made on a connection with profile p.
+	Observe(p Profile, o Outcome)
+}
+
+// ParseStrategy returns the strategy with the given name between profiles: "fixed" for the first of them,
+// "round-robin", or "weighted". The empty name is fixed for a single profile, and round-robin otherwise.
+// With no profiles, DefaultProfile is used.
+func ParseStrategy(name string, profiles []Profile) (Strategy, error) {
+	if len(profiles) == 0 {
+		profiles = []Profile{DefaultProfile}
+	}
+
+	switch strings.ToLower(name) {
+	case "":
+		if len(profiles) == 1 {
+			return Fixed(profiles[0]), nil
+		}
+		return NewRoundRobin(profiles...), nil
+	case "fixed":
+		return Fixed(profiles[0]), nil
+	case "round-robin":
+		return NewRoundRobin(profiles...), nil
+	case "weighted":
+		return NewWeighted(rand.Int63(), profiles...), nil
+	default:
+		return nil, fmt.Errorf("unknown TLS profile strategy %q", name)
+	}
+}
+
+// Fixed is a Strategy that always picks the same profile.
+func Fixed(p Profile) Strategy {
+	return fixed{p}
+}
+
+type fixed struct {
+	p Profile
+}
+
+func (f fixed) Next() Profile {
+	return f.p
+}
+
+func (fixed) Observe(Profile, Outcome) {}
+
+// RoundRobin is a Strategy that picks each of its profiles in turn.
+type RoundRobin struct {
+	mu       sync.Mutex
+	profiles []Profile
+	next     int
+}
+
+func NewRoundRobin(profiles ...Profile) *RoundRobin {
+	return &RoundRobin{profiles: profiles}
+}
+
+func (r *RoundRobin) Next() Profile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.profiles[r.next%len(r.profiles)]
+	r.next++
+	return p
+}
+
+func (*RoundRobin) Observe(Profile, Outcome) {}
+
+const (
+	// weightDecay is the weight of each new outcome in the success rate of a profile,
+	// so that a profile that starts getting blocked is dropped within a few dozen requests.
+	weightDecay = 0.1
+	// minWeight keeps every profile picked now and then, so that one that recovers is noticed.
+	minWeight = 0.05
+)
+
+// Weighted is a Strategy that picks profiles at random, weighted by their recent rate of success.
+// Every profile starts out with a perfect rate.
+type Weighted struct {
+	mu       sync.Mutex
+	rand     *rand.Rand
+	profiles []Profile
+	rates    map[string]float64
+}
+
+// NewWeighted returns a Weighted strategy between profiles, seeded with seed.
+func NewWeighted(seed int64, profiles ...Profile) *Weighted {
+	w := &Weighted{
+		rand:     rand.New(rand.NewSource(seed)),
+		profiles: profiles,
+		rates:    make(map[string]float64, len(profiles)),
+	}
+	for _, p := range profiles {
+		w.rates[p.Name] = 1
+	}
+
+	return w
+}
+
+func (w *Weighted) Next() Profile {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var total float64
+	for _, p := range w.profiles {
+		total += w.weight(p)
+	}
+
+	x := w.rand.Float64() * total
+	for _, p := range w.profiles {
+		if x -= w.weight(p); x < 0 {
+			return p
+		}
+	}
+
+	return w.profiles[len(w.profiles)-1]
+}
+
+func (w *Weighted) weight(p Profile) float64 {
+	if rate := w.rates[p.Name]; rate > minWeight {
+		return rate
+	}
+
+	return minWeight
+}
+
+func (w *Weighted) Observe(p Profile, o Outcome) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var success float64
+	if o == Succeeded {
+		success = 1
+	}
+	if rate, ok := w.rates[p.Name]; ok {
+		w.rates[p.Name] = rate + weightDecay*(success-rate)
+	}
+}
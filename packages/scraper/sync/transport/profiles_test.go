@@ -0,0 +1,145 @@
+package transport
+
+import (
+	"testing"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tls12Spec is a plain TLS 1.2 ClientHello, unlike any of the browsers'.
+func tls12Spec() *tls.ClientHelloSpec {
+	return &tls.ClientHelloSpec{
+		TLSVersMin: tls.VersionTLS12,
+		TLSVersMax: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		},
+		CompressionMethods: []uint8{0},
+		Extensions: []tls.TLSExtension{
+			&tls.SNIExtension{},
+			&tls.SupportedCurvesExtension{Curves: []tls.CurveID{tls.X25519, tls.CurveP256}},
+			&tls.SupportedPointsExtension{SupportedPoints: []uint8{0}},
+			&tls.SignatureAlgorithmsExtension{SupportedSignatureAlgorithms: []tls.SignatureScheme{
+				tls.ECDSAWithP256AndSHA256,
+				tls.PSSWithSHA256,
+				tls.PKCS1WithSHA256,
+			}},
+			&tls.ALPNExtension{AlpnProtocols: []string{"http/1.1"}},
+			&tls.UtlsExtendedMasterSecretExtension{},
+		},
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	type testCase struct {
+		name    string
+		helloID tls.ClientHelloID
+	}
+
+	for _, tc := range []testCase{
+		{name: "chrome", helloID: tls.HelloChrome_Auto},
+		{name: "Chrome-72", helloID: tls.HelloChrome_72},
+		{name: " firefox ", helloID: tls.HelloFirefox_Auto},
+		{name: "safari", helloID: tls.HelloIOS_Auto},
+		{name: "randomized-no-alpn", helloID: tls.HelloRandomizedNoALPN},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParseProfile(tc.name)
+			require.NoError(t, err)
+			assert.Equal(t, tc.helloID, p.HelloID)
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := ParseProfile("netscape")
+		assert.ErrorContains(t, err, "netscape")
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		RegisterProfile(CustomProfile("TLS12", tls12Spec))
+		defer func() {
+			registeredMu.Lock()
+			delete(registered, "tls12")
+			registeredMu.Unlock()
+		}()
+
+		p, err := ParseProfile("tls12")
+		require.NoError(t, err)
+		assert.Equal(t, "TLS12", p.Name)
+		assert.Equal(t, tls.HelloCustom, p.HelloID)
+		require.NotNil(t, p.Spec)
+
+		assert.Panics(t, func() { RegisterProfile(CustomProfile("tls12", tls12Spec)) })
+		assert.Panics(t, func() { RegisterProfile(CustomProfile("chrome", tls12Spec)) })
+	})
+}
+
+func TestParseStrategy(t *testing.T) {
+	chrome, firefox := Profile{Name: "chrome"}, Profile{Name: "firefox"}
+
+	s, err := ParseStrategy("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultProfile, s.Next())
+
+	s, err = ParseStrategy("", []Profile{chrome, firefox})
+	require.NoError(t, err)
+	assert.IsType(t, &RoundRobin{}, s)
+
+	s, err = ParseStrategy("fixed", []Profile{chrome, firefox})
+	require.NoError(t, err)
+	assert.Equal(t, chrome, s.Next())
+	assert.Equal(t, chrome, s.Next())
+
+	s, err = ParseStrategy("weighted", []Profile{chrome, firefox})
+	require.NoError(t, err)
+	assert.IsType(t, &Weighted{}, s)
+
+	_, err = ParseStrategy("lottery", []Profile{chrome})
+	assert.Error(t, err)
+}
+
+func TestRoundRobin(t *testing.T) {
+	a, b, c := Profile{Name: "a"}, Profile{Name: "b"}, Profile{Name: "c"}
+	r := NewRoundRobin(a, b, c)
+
+	var names []string
+	for i := 0; i < 5; i++ {
+		names = append(names, r.Next().Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a", "b"}, names)
+}
+
+func TestWeighted(t *testing.T) {
+	blocked, fine := Profile{Name: "blocked"}, Profile{Name: "fine"}
+	w := NewWeighted(1, blocked, fine)
+
+	counts := func() map[string]int {
+		counts := make(map[string]int)
+		for i := 0; i < 1000; i++ {
+			counts[w.Next().Name]++
+		}
+		return counts
+	}
+
+	before := counts()
+	assert.InDelta(t, 500, before["blocked"], 100)
+
+	for i := 0; i < 50; i++ {
+		w.Observe(blocked, Forbidden)
+		w.Observe(fine, Succeeded)
+	}
+
+	after := counts()
+	assert.Less(t, after["blocked"], 100)
+	assert.Greater(t, after["blocked"], 0, "a blocked profile should still be tried now and then")
+
+	// a profile that recovers comes back
+	for i := 0; i < 50; i++ {
+		w.Observe(blocked, Succeeded)
+	}
+	assert.InDelta(t, 500, counts()["blocked"], 100)
+}
@@ -0,0 +1,15 @@
+// Package profilestats counts the outcomes of requests by the TLS profile they were made with. It's apart from
+// package transport so that readers of the counts, such as the orchestrator, don't depend on utls.
+package profilestats
+
+// Stats counts the outcomes of the requests made with a TLS profile.
+type Stats struct {
+	Requests  int `json:"requests"`
+	Successes int `json:"successes"`
+	// Throttled counts 429 responses.
+	Throttled int `json:"throttled"`
+	// Forbidden counts 403 responses.
+	Forbidden int `json:"forbidden"`
+	// Errors counts requests that got no response, including those whose TLS handshake failed.
+	Errors int `json:"errors"`
+}
@@ -0,0 +1,333 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/sync/singleflight"
+)
+
+// errProtocolChanged is returned by the dials of a RoundTripper's transports when the new connection
+// negotiated the other protocol, so that the request is retried with the other transport.
+var errProtocolChanged = errors.New("connection negotiated a different protocol than its host's")
+
+// RoundTripper is an http.RoundTripper over the TLS connections of a Dialer that speaks the protocol they
+// negotiate with ALPN: HTTP/2 when the server picks h2, as a browser sending the same ClientHello would,
+// and HTTP/1.1 otherwise.
+//
+// The protocol of a host is learned from the first connection to it, and again whenever a new connection
+// negotiates the other one, as can happen when the profiles picked by a Strategy differ in what they offer.
+type RoundTripper struct {
+	Dialer *Dialer
+	// HTTP1 makes the requests to hosts that negotiated HTTP/1.1, and the plain HTTP ones.
+	HTTP1 *http.Transport
+	// HTTP2 makes the requests to hosts that negotiated h2, multiplexing them over as few connections as it can.
+	HTTP2 *http2.Transport
+
+	mu        sync.Mutex
+	protocols map[string]string
+	// pending holds the connections dialed to learn a protocol, until the transport for it dials the same address.
+	pending map[pendingKey]net.Conn
+	// learning shares the dial that learns the protocol of an address between the first requests to it
+	learning singleflight.Group
+	h2       *h2Pool
+}
+
+type pendingKey struct {
+	addr, protocol string
+}
+
+// NewRoundTripper returns a RoundTripper over the connections of d.
+func NewRoundTripper(d *Dialer) *RoundTripper {
+	rt := &RoundTripper{
+		Dialer:    d,
+		protocols: make(map[string]string),
+		pending:   make(map[pendingKey]net.Conn),
+	}
+	rt.HTTP1 = d.Transport()
+	rt.HTTP1.DialTLSContext = rt.dialHTTP1
+	rt.h2 = &h2Pool{rt: rt, conns: make(map[string][]*http2.ClientConn)}
+	rt.HTTP2 = &http2.Transport{
+		// the pool dials, since DialTLS isn't given the context of the request
+		ConnPool: rt.h2,
+		// notice connections that died quietly, e.g. dropped by a proxy
+		ReadIdleTimeout: 30 * time.Second,
+	}
+
+	return rt
+}
+
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return rt.HTTP1.RoundTrip(req)
+	}
+
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		addr = net.JoinHostPort(req.URL.Hostname(), "443")
+	}
+
+	for retried := false; ; retried = true {
+		protocol, err := rt.protocol(req.Context(), addr)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp *http.Response
+		if protocol == http2.NextProtoTLS {
+			resp, err = rt.HTTP2.RoundTrip(req)
+		} else {
+			resp, err = rt.HTTP1.RoundTrip(req)
+		}
+		if retried || !errors.Is(err, errProtocolChanged) {
+			return resp, err
+		}
+
+		// nothing was sent, but the transport closed the body
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return nil, err
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// CloseIdleConnections closes the idle connections of both transports, and those dialed but not used yet.
+func (rt *RoundTripper) CloseIdleConnections() {
+	rt.HTTP1.CloseIdleConnections()
+	rt.h2.closeIdle()
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	for key, conn := range rt.pending {
+		conn.Close()
+		delete(rt.pending, key)
+	}
+}
+
+// protocol returns the protocol of addr, dialing it to find out if it's not known yet.
+func (rt *RoundTripper) protocol(ctx context.Context, addr string) (string, error) {
+	for {
+		rt.mu.Lock()
+		protocol, ok := rt.protocols[addr]
+		rt.mu.Unlock()
+		if ok {
+			return protocol, nil
+		}
+
+		// concurrent first requests to addr share a dial
+		learned := rt.learning.DoChan(addr, func() (interface{}, error) {
+			conn, err := rt.Dialer.DialTLSContext(ctx, "tcp", addr)
+			if err != nil {
+				return nil, err
+			}
+
+			protocol := negotiated(conn)
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.protocols[addr] = protocol
+			rt.keep(addr, protocol, conn)
+
+			return protocol, nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case res := <-learned:
+			if res.Err == nil {
+				return res.Val.(string), nil
+			}
+			if !cutShort(ctx, res.Err) {
+				return "", res.Err
+			}
+		}
+	}
+}
+
+// cutShort reports whether err, from a dial shared with other requests, came from the context of another one,
+// while ctx is still live.
+func cutShort(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
+
+func (rt *RoundTripper) dialHTTP1(ctx context.Context, network, addr string) (net.Conn, error) {
+	return rt.dial(ctx, network, addr, "")
+}
+
+// dial returns a connection to addr speaking protocol, preferring the one dialed to learn it.
+// If a new connection negotiates the other protocol, it's kept for the other transport and errProtocolChanged
+// is returned instead.
+func (rt *RoundTripper) dial(ctx context.Context, network, addr, protocol string) (net.Conn, error) {
+	if conn := rt.take(addr, protocol); conn != nil {
+		return conn, nil
+	}
+
+	conn, err := rt.Dialer.DialTLSContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if got := negotiated(conn); got != protocol {
+		rt.mu.Lock()
+		rt.protocols[addr] = got
+		rt.keep(addr, got, conn)
+		rt.mu.Unlock()
+		return nil, errProtocolChanged
+	}
+
+	return conn, nil
+}
+
+// keep holds conn until the transport for protocol dials addr, replacing any connection already held.
+// rt.mu must be held.
+func (rt *RoundTripper) keep(addr, protocol string, conn net.Conn) {
+	key := pendingKey{addr, protocol}
+	if old, ok := rt.pending[key]; ok {
+		old.Close()
+	}
+	rt.pending[key] = conn
+}
+
+func (rt *RoundTripper) take(addr, protocol string) net.Conn {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	key := pendingKey{addr, protocol}
+	conn := rt.pending[key]
+	delete(rt.pending, key)
+
+	return conn
+}
+
+// negotiated returns the protocol that conn negotiated: h2, or the empty string for HTTP/1.1.
+func negotiated(conn net.Conn) string {
+	c, ok := conn.(*Conn)
+	if !ok {
+		return ""
+	}
+
+	// servers fall back on HTTP/1.1 without ALPN, or for a protocol they don't know
+	if protocol := c.ConnectionState().NegotiatedProtocol; protocol == http2.NextProtoTLS {
+		return protocol
+	}
+
+	return ""
+}
+
+// h2Pool is the http2.ClientConnPool of a RoundTripper. Unlike the default pool, it dials under the context of
+// the request that needs a connection.
+type h2Pool struct {
+	rt *RoundTripper
+	// dialing shares the dial of a new connection to an address between the requests waiting for one
+	dialing singleflight.Group
+
+	mu    sync.Mutex
+	conns map[string][]*http2.ClientConn
+}
+
+func (p *h2Pool) GetClientConn(req *http.Request, addr string) (*http2.ClientConn, error) {
+	ctx := req.Context()
+	for {
+		if cc := p.reserve(addr); cc != nil {
+			return cc, nil
+		}
+
+		dialed := p.dialing.DoChan(addr, func() (interface{}, error) {
+			conn, err := p.rt.dial(ctx, "tcp", addr, http2.NextProtoTLS)
+			if err != nil {
+				return nil, err
+			}
+			cc, err := p.rt.HTTP2.NewClientConn(conn)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.conns[addr] = append(p.conns[addr], cc)
+
+			return cc, nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case res := <-dialed:
+			if res.Err != nil {
+				if cutShort(ctx, res.Err) {
+					continue
+				}
+				return nil, res.Err
+			}
+			// the new connection may already be full with the requests that shared its dial
+			if cc := res.Val.(*http2.ClientConn); cc.ReserveNewRequest() {
+				return cc, nil
+			}
+		}
+	}
+}
+
+// reserve returns a connection to addr with room for another request, reserved for it, or nil if there is none.
+func (p *h2Pool) reserve(addr string) *http2.ClientConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, cc := range p.conns[addr] {
+		if cc.ReserveNewRequest() {
+			return cc
+		}
+	}
+
+	return nil
+}
+
+func (p *h2Pool) MarkDead(dead *http2.ClientConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, conns := range p.conns {
+		for i, cc := range conns {
+			if cc == dead {
+				p.conns[addr] = append(conns[:i:i], conns[i+1:]...)
+				break
+			}
+		}
+		if len(p.conns[addr]) == 0 {
+			delete(p.conns, addr)
+		}
+	}
+}
+
+// closeIdle shuts down the connections without requests, letting any that start meanwhile finish.
+func (p *h2Pool) closeIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, conns := range p.conns {
+		kept := conns[:0:0]
+		for _, cc := range conns {
+			if st := cc.State(); st.StreamsActive > 0 || st.StreamsReserved > 0 || st.StreamsPending > 0 {
+				kept = append(kept, cc)
+				continue
+			}
+			go cc.Shutdown(context.Background())
+		}
+		if len(kept) == 0 {
+			delete(p.conns, addr)
+		} else {
+			p.conns[addr] = kept
+		}
+	}
+}
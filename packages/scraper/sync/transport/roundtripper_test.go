@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTripper(t *testing.T) {
+	// protoHandler answers with the protocol of the request and its body
+	protoHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, r.Proto+" "+string(body))
+	})
+
+	newServer := func(t *testing.T, h2 bool) (*helloServer, *x509.CertPool) {
+		s := &helloServer{}
+		s.Server = newUnstartedHelloServer(s, protoHandler)
+		s.EnableHTTP2 = h2
+		s.StartTLS()
+		t.Cleanup(s.Close)
+
+		roots := x509.NewCertPool()
+		roots.AddCert(s.Certificate())
+		return s, roots
+	}
+
+	post := func(t *testing.T, rt http.RoundTripper, url, body string) string {
+		resp, err := (&http.Client{Transport: rt}).Post(url, "text/plain", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	t.Run("multiplexes over h2", func(t *testing.T) {
+		server, roots := newServer(t, true)
+		rt := NewRoundTripper(&Dialer{HelloID: tls.HelloChrome_83, Config: &tls.Config{RootCAs: roots}})
+
+		cl := &http.Client{Transport: rt}
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := cl.Post(server.URL, "text/plain", strings.NewReader("batch"))
+				if err == nil {
+					resp.Body.Close()
+					if resp.Proto != "HTTP/2.0" {
+						err = fmt.Errorf("got %s", resp.Proto)
+					}
+				}
+				errs[i] = err
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+
+		fingerprints := server.JA3s(t)
+		assert.Equal(t, []string{profileJA3(t, tls.HelloChrome_83)}, fingerprints, "a single connection")
+	})
+
+	t.Run("falls back on HTTP/1.1", func(t *testing.T) {
+		server, roots := newServer(t, false)
+		rt := NewRoundTripper(&Dialer{HelloID: tls.HelloChrome_83, Config: &tls.Config{RootCAs: roots}})
+
+		assert.Equal(t, "HTTP/1.1 a", post(t, rt, server.URL, "a"))
+		assert.Equal(t, "HTTP/1.1 b", post(t, rt, server.URL, "b"))
+		assert.Len(t, server.JA3s(t), 1, "the connection dialed to learn the protocol is reused")
+	})
+
+	t.Run("follows profiles that differ in ALPN", func(t *testing.T) {
+		server, roots := newServer(t, true)
+		strategy := NewRoundRobin(
+			CustomProfile("tls12", tls12Spec), // offers only http/1.1
+			Profile{Name: "chrome-83", HelloID: tls.HelloChrome_83},
+		)
+		rt := NewRoundTripper(&Dialer{Strategy: strategy, Config: &tls.Config{RootCAs: roots}})
+		rt.HTTP1.DisableKeepAlives = true
+
+		assert.Equal(t, "HTTP/1.1 first", post(t, rt, server.URL, "first"))
+		// the next connection negotiates h2, so the request is replayed over it
+		assert.Equal(t, "HTTP/2.0 second", post(t, rt, server.URL, "second"))
+		assert.Equal(t, "HTTP/2.0 third", post(t, rt, server.URL, "third"))
+		assert.Len(t, server.JA3s(t), 2)
+	})
+
+	t.Run("dials h2 under the request's context", func(t *testing.T) {
+		server, roots := newServer(t, true)
+		rt := NewRoundTripper(&Dialer{HelloID: tls.HelloChrome_83, Config: &tls.Config{RootCAs: roots}})
+		assert.Equal(t, "HTTP/2.0 a", post(t, rt, server.URL, "a"))
+
+		// the host's protocol is known, but its next connection never gets past the handshake
+		rt.CloseIdleConnections()
+		addr := server.Listener.Addr().String()
+		server.Close()
+		l, err := net.Listen("tcp", addr)
+		require.NoError(t, err)
+		defer l.Close()
+		hungUp := make(chan struct{})
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+			close(hungUp)
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = rt.RoundTrip(req)
+		// either the context or the deadline it set on the connection
+		var timeout net.Error
+		require.ErrorAs(t, err, &timeout)
+		assert.True(t, timeout.Timeout())
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		select {
+		case <-hungUp:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the dial outlived its request")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport/profilestats"
+)
+
+// add counts an outcome in s.
+func add(s *profilestats.Stats, o Outcome) {
+	s.Requests++
+	switch o {
+	case Succeeded:
+		s.Successes++
+	case Throttled:
+		s.Throttled++
+	case Forbidden:
+		s.Forbidden++
+	case Failed:
+		s.Errors++
+	}
+}
+
+// Tracker is an http.RoundTripper that tells Strategy the outcome of every request made on a connection
+// dialed by a Dialer, and counts them by profile. Handshakes that fail count as failed requests.
+type Tracker struct {
+	Transport http.RoundTripper
+	Strategy  Strategy
+
+	mu    sync.Mutex
+	stats map[string]profilestats.Stats
+}
+
+// NewTracker returns a Tracker of the requests made with d, which it watches.
+func NewTracker(d *Dialer, strategy Strategy) *Tracker {
+	t := &Tracker{Transport: NewRoundTripper(d), Strategy: strategy}
+	t.Watch(d)
+
+	return t
+}
+
+// Watch sets d to pick profiles with t.Strategy and to tell t about the handshakes that fail, which no request
+// through t.Transport would. Every Dialer under t.Transport must be watched for its failed handshakes to count.
+func (t *Tracker) Watch(d *Dialer) {
+	d.Strategy = t.Strategy
+	d.OnHandshakeError = func(p Profile, _ error) { t.observe(p, Failed) }
+}
+
+func (t *Tracker) RoundTrip(req *http.Request) (*http.Response, error) {
+	var conn *Conn
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			conn, _ = info.Conn.(*Conn)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	resp, err := t.Transport.RoundTrip(req)
+	if conn == nil {
+		// plain HTTP, or no connection was made at all, in which case a failed handshake was already observed
+		return resp, err
+	}
+
+	outcome := Succeeded
+	switch {
+	case err != nil:
+		outcome = Failed
+	case resp.StatusCode == http.StatusTooManyRequests:
+		outcome = Throttled
+	case resp.StatusCode == http.StatusForbidden:
+		outcome = Forbidden
+	}
+	t.observe(conn.Profile, outcome)
+
+	return resp, err
+}
+
+func (t *Tracker) observe(p Profile, o Outcome) {
+	if t.Strategy != nil {
+		t.Strategy.Observe(p, o)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stats == nil {
+		t.stats = make(map[string]profilestats.Stats)
+	}
+	s := t.stats[p.Name]
+	add(&s, o)
+	t.stats[p.Name] = s
+}
+
+// Stats returns the counts of outcomes so far by profile name.
+func (t *Tracker) Stats() map[string]profilestats.Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]profilestats.Stats, len(t.stats))
+	for name, s := range t.stats {
+		stats[name] = s
+	}
+
+	return stats
+}
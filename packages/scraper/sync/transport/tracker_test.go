@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"crypto/x509"
+	"io"
+	"net/http"
+	"testing"
+
+	tls "github.com/refraction-networking/utls"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/suremarc/go-rblx-asset-scraper/packages/scraper/sync/transport/profilestats"
+)
+
+func TestTracker(t *testing.T) {
+	server := newHelloServerWithHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/throttled":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	chrome := Profile{Name: "chrome-83", HelloID: tls.HelloChrome_83}
+	firefox := Profile{Name: "firefox-65", HelloID: tls.HelloFirefox_65}
+	custom := CustomProfile("tls12", tls12Spec)
+
+	tracker := NewTracker(&Dialer{Config: &tls.Config{RootCAs: roots}}, NewRoundRobin(chrome, firefox, custom))
+	// a connection per request, so that every request gets the next profile
+	tracker.Transport.(*RoundTripper).HTTP1.DisableKeepAlives = true
+	cl := &http.Client{Transport: tracker}
+
+	for _, path := range []string{"/", "/throttled", "/forbidden", "/"} {
+		resp, err := cl.Get(server.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, map[string]profilestats.Stats{
+		"chrome-83":  {Requests: 2, Successes: 2},
+		"firefox-65": {Requests: 1, Throttled: 1},
+		"tls12":      {Requests: 1, Forbidden: 1},
+	}, tracker.Stats())
+
+	assert.Equal(t, []string{
+		customJA3(t, chrome),
+		customJA3(t, firefox),
+		customJA3(t, custom),
+		customJA3(t, chrome),
+	}, server.JA3s(t))
+	assert.NotEqual(t, customJA3(t, chrome), customJA3(t, custom))
+
+	t.Run("counts failed handshakes", func(t *testing.T) {
+		// without the server's certificate, every handshake fails
+		tracker := NewTracker(&Dialer{}, Fixed(chrome))
+		cl := &http.Client{Transport: tracker}
+
+		for i := 0; i < 2; i++ {
+			_, err := cl.Get(server.URL)
+			require.Error(t, err)
+		}
+
+		assert.Equal(t, map[string]profilestats.Stats{"chrome-83": {Requests: 2, Errors: 2}}, tracker.Stats())
+	})
+}